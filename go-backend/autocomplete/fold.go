@@ -0,0 +1,37 @@
+// Package autocomplete completes card names from a prefix using an
+// in-memory trie keyed on folded names.
+package autocomplete
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ligatures aren't decomposed by NFD, so they are spelled out by hand
+var ligatures = strings.NewReplacer("æ", "ae", "Æ", "ae", "œ", "oe", "Œ", "oe")
+
+// Fold reduces a name to the form the trie is keyed on: accents removed,
+// lower case, hyphens read as spaces, other punctuation dropped and runs of
+// spaces collapsed. "Lim-Dûl the Necromancer" and "lim dul" share a prefix.
+func Fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	stripped, _, err := transform.String(t, ligatures.Replace(s))
+	if err != nil {
+		stripped = s
+	}
+	folded := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case unicode.IsSpace(r) || r == '-' || r == '‐' || r == '–':
+			return ' '
+		default:
+			return -1
+		}
+	}, stripped)
+	return strings.Join(strings.Fields(folded), " ")
+}
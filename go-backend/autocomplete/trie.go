@@ -0,0 +1,115 @@
+package autocomplete
+
+import (
+	"sort"
+	"strings"
+)
+
+// Trie maps folded name prefixes to card names. It is never modified after
+// Build, so any number of readers can share it; refreshing means building a
+// new one and swapping it in.
+type Trie struct {
+	root  *node
+	names int
+}
+
+type node struct {
+	children map[rune]*node
+	keys     []rune   // children's runes, sorted so walks come out in order
+	names    []string // names whose folded key ends here
+}
+
+// Build indexes names. Each face of a multi-faced name such as
+// "Delver of Secrets // Insectile Aberration" is indexed too, so typing
+// either face completes to the full name.
+func Build(names []string) *Trie {
+	t := &Trie{root: &node{}}
+	for _, name := range names {
+		t.insert(Fold(name), name)
+		if strings.Contains(name, "//") {
+			for _, face := range strings.Split(name, "//") {
+				t.insert(Fold(face), name)
+			}
+		}
+		t.names++
+	}
+	t.root.finish()
+	return t
+}
+
+func (t *Trie) insert(key, name string) {
+	if key == "" {
+		return
+	}
+	n := t.root
+	for _, r := range key {
+		child, ok := n.children[r]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[rune]*node)
+			}
+			child = &node{}
+			n.children[r] = child
+		}
+		n = child
+	}
+	for _, existing := range n.names {
+		if existing == name {
+			return
+		}
+	}
+	n.names = append(n.names, name)
+}
+
+// finish sorts every node's child runes and names for ordered walks.
+func (n *node) finish() {
+	n.keys = make([]rune, 0, len(n.children))
+	for r, child := range n.children {
+		n.keys = append(n.keys, r)
+		child.finish()
+	}
+	sort.Slice(n.keys, func(i, j int) bool { return n.keys[i] < n.keys[j] })
+	sort.Strings(n.names)
+}
+
+// Len is the number of names indexed.
+func (t *Trie) Len() int { return t.names }
+
+// Complete returns up to limit distinct names starting with prefix, after
+// folding both. Shorter keys come first, then alphabetical order.
+func (t *Trie) Complete(prefix string, limit int) []string {
+	out := []string{}
+	key := Fold(prefix)
+	if key == "" || limit <= 0 {
+		return out
+	}
+	n := t.root
+	for _, r := range key {
+		if n = n.children[r]; n == nil {
+			return out
+		}
+	}
+
+	seen := make(map[string]bool)
+	// breadth-first so "Shock" isn't buried under every "Shock..." name
+	level := []*node{n}
+	for len(level) > 0 && len(out) < limit {
+		var next []*node
+		for _, n := range level {
+			for _, name := range n.names {
+				if !seen[name] {
+					seen[name] = true
+					out = append(out, name)
+					if len(out) == limit {
+						return out
+					}
+				}
+			}
+			for _, r := range n.keys {
+				next = append(next, n.children[r])
+			}
+		}
+		level = next
+	}
+	return out
+}
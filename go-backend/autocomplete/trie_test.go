@@ -0,0 +1,72 @@
+package autocomplete
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Lim-Dûl the Necromancer", "lim dul the necromancer"},
+		{"Junún Efreet", "junun efreet"},
+		{"Jötun Grunt", "jotun grunt"},
+		{"Æther Vial", "aether vial"},
+		{"Sæmundr", "saemundr"},
+		{"Œuvre", "oeuvre"},
+		{`Kongming, "Sleeping Dragon"`, "kongming sleeping dragon"},
+		{"Delver of Secrets // Insectile Aberration", "delver of secrets insectile aberration"},
+		{"  Lim–Dûl's   Vault ", "lim duls vault"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	trie := Build([]string{
+		"Lim-Dûl the Necromancer", "Lim-Dûl's Vault", "Junún Efreet", "Æther Vial", "Aetherflux Reservoir",
+		"Delver of Secrets // Insectile Aberration", "Fire // Ice", "Ice Cauldron",
+		"Shock", "Shocker", "Shockwave", "Shock Troops",
+	})
+	if got := trie.Len(); got != 12 {
+		t.Errorf("Len = %d, want 12", got)
+	}
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		// accents and hyphens fold away on both sides
+		{"lim dul", 10, []string{"Lim-Dûl's Vault", "Lim-Dûl the Necromancer"}},
+		{"Lim-Dûl t", 10, []string{"Lim-Dûl the Necromancer"}},
+		{"LIMDUL", 10, []string{}},
+		{"junun", 10, []string{"Junún Efreet"}},
+		{"Junún", 10, []string{"Junún Efreet"}},
+		// Æ reads as "ae" whichever way it is typed
+		{"aether", 10, []string{"Æther Vial", "Aetherflux Reservoir"}},
+		{"Æther", 10, []string{"Æther Vial", "Aetherflux Reservoir"}},
+		// each face completes to the full name, which comes out once
+		{"insectile", 10, []string{"Delver of Secrets // Insectile Aberration"}},
+		{"delver", 10, []string{"Delver of Secrets // Insectile Aberration"}},
+		{"ice", 10, []string{"Fire // Ice", "Ice Cauldron"}},
+		{"fire", 10, []string{"Fire // Ice"}},
+		// breadth-first: shorter keys before longer ones
+		{"shock", 10, []string{"Shock", "Shocker", "Shockwave", "Shock Troops"}},
+		{"shock", 2, []string{"Shock", "Shocker"}},
+		{"sho", 1, []string{"Shock"}},
+		{"shock t", 10, []string{"Shock Troops"}},
+		{"shockz", 10, []string{}},
+		{"", 10, []string{}},
+		{"  -", 10, []string{}},
+		{"shock", 0, []string{}},
+	}
+	for _, tt := range tests {
+		if got := trie.Complete(tt.prefix, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q, %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+		}
+	}
+}
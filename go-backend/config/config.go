@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type PGConfig struct {
 	Port    int     `env:"PG_PORT" envDefault:"5432"`
 	Host    string  `env:"DATABASE_HOST,required"`
@@ -14,4 +16,19 @@ type MGConfig struct {
     Port     int    `env:"MG_PORT" envDefault:"7687"`
     User     string `env:"MG_USER" envDefault:""`
     Pass     string `env:"MG_PASS" envDefault:""`
-}
\ No newline at end of file
+	// Optional JSON rules replacing the built-in mechanics rules
+	MechanicsRulesFile string `env:"MECHANICS_RULES_FILE"`
+}
+
+type ScryfallConfig struct {
+	BaseURL         string        `env:"SCRYFALL_BASE_URL" envDefault:"https://api.scryfall.com"`
+	BulkType        string        `env:"SCRYFALL_BULK_TYPE" envDefault:"default_cards"`
+	RefreshEnabled  bool          `env:"SCRYFALL_REFRESH" envDefault:"false"`
+	RefreshInterval time.Duration `env:"SCRYFALL_REFRESH_INTERVAL" envDefault:"24h"`
+	DownloadDir     string        `env:"SCRYFALL_DOWNLOAD_DIR" envDefault:""`
+}
+
+type AdminConfig struct {
+	// Bearer token for the /api/admin routes; they are not served without one
+	Token string `env:"ADMIN_TOKEN"`
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BulkType is one of the files Scryfall publishes at /bulk-data. The values
+// match the `type` field of the bulk-data manifest.
+type BulkType string
+
+const (
+	BulkOracleCards   BulkType = "oracle_cards"
+	BulkUniqueArtwork BulkType = "unique_artwork"
+	BulkDefaultCards  BulkType = "default_cards"
+	BulkAllCards      BulkType = "all_cards"
+	BulkRulings       BulkType = "rulings"
+)
+
+// bulkSpec describes what records of a bulk type look like.
+type bulkSpec struct {
+	// object is the Scryfall `object` value every record should carry
+	object string
+	// required fields; records missing any of them are skipped
+	required []string
+	// idField is the field recorded in the checkpoint as the last record seen
+	idField string
+}
+
+var bulkSpecs = map[BulkType]bulkSpec{
+	BulkOracleCards:   {object: "card", required: []string{"id", "name", "oracle_id"}, idField: "id"},
+	BulkUniqueArtwork: {object: "card", required: []string{"id", "name"}, idField: "id"},
+	BulkDefaultCards:  {object: "card", required: []string{"id", "name"}, idField: "id"},
+	BulkAllCards:      {object: "card", required: []string{"id", "name"}, idField: "id"},
+	BulkRulings:       {object: "ruling", required: []string{"oracle_id", "comment"}, idField: "oracle_id"},
+}
+
+// ParseBulkType accepts either the manifest spelling ("oracle_cards") or the
+// file name spelling ("oracle-cards").
+func ParseBulkType(s string) (BulkType, error) {
+	t := BulkType(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "-", "_"))
+	if _, ok := bulkSpecs[t]; !ok {
+		return "", fmt.Errorf("unknown bulk type %q (want oracle-cards, unique-artwork, default-cards, all-cards or rulings)", s)
+	}
+	return t, nil
+}
+
+// validate reports whether a record has the fields its bulk type needs. A
+// record of the wrong object kind is an error since it means the wrong
+// --bulk-type was given for the file.
+func (s bulkSpec) validate(raw map[string]interface{}) (bool, error) {
+	if object, ok := raw["object"].(string); ok && object != s.object {
+		return false, fmt.Errorf("found a %q record where a %q was expected; check --bulk-type", object, s.object)
+	}
+	for _, field := range s.required {
+		if v, ok := raw[field]; !ok || v == nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// openBulkReader transparently decompresses gzip input. A seekable plain
+// file is returned as-is so checkpoints can resume by seeking; gzip streams
+// cannot seek and resume by skipping records instead.
+func openBulkReader(file io.Reader) (io.Reader, error) {
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		magic := make([]byte, 2)
+		n, err := io.ReadFull(seeker, magic)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, fmt.Errorf("failed to read file header: %w", err)
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind file: %w", err)
+		}
+		if n == 2 && isGzip(magic) {
+			return gzip.NewReader(seeker)
+		}
+		return file, nil
+	}
+
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(2)
+	if isGzip(magic) {
+		return gzip.NewReader(reader)
+	}
+	return reader, nil
+}
+
+func isGzip(header []byte) bool {
+	return len(header) >= 2 && header[0] == 0x1f && header[1] == 0x8b
+}
+
+// peekIsArray skips leading whitespace (and a UTF-8 BOM) and reports whether
+// the stream is a JSON array. Anything else is treated as NDJSON. It also
+// returns how many bytes were skipped.
+func peekIsArray(reader *bufio.Reader) (bool, int64, error) {
+	var skipped int64
+	if bom, err := reader.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		reader.Discard(3)
+		skipped += 3
+	}
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return false, skipped, fmt.Errorf("failed to read start of file: %w", err)
+		}
+		switch b[0] {
+		case ' ', '\n', '\r', '\t':
+			reader.Discard(1)
+			skipped++
+		case '[':
+			return true, skipped, nil
+		case '{':
+			return false, skipped, nil
+		default:
+			return false, skipped, fmt.Errorf("expected a JSON array or NDJSON, found %q", b[0])
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"go-backend/config"
+	"go-backend/mechanics"
 	"log"
 	"strings"
 
@@ -57,6 +58,15 @@ func InitializeDatabase(models ...interface{}) {
 	} else {
 		fmt.Println("Database: Schema migrated successfully")
 	}
+	if err := migrateFullText(); err != nil {
+		log.Fatalf("Database: %v", err)
+	}
+	if err := runDataMigrations(); err != nil {
+		log.Fatalf("Database: %v", err)
+	}
+	if err := migrateLegalityIndex(); err != nil {
+		log.Fatalf("Database: %v", err)
+	}
 }
 
 func InitializeMemgraph() {
@@ -74,6 +84,15 @@ func InitializeMemgraph() {
 	}
 	GraphDriver = driver
 
+	if cfg.MechanicsRulesFile != "" {
+		engine, err := mechanics.LoadFile(cfg.MechanicsRulesFile)
+		if err != nil {
+			log.Fatalf("Memgraph: Failed to load mechanics rules: %v", err)
+		}
+		mechanics.SetDefault(engine)
+		fmt.Printf("Memgraph: Using mechanics rules from %s\n", cfg.MechanicsRulesFile)
+	}
+
 	// Ensure our "Lean Schema" Constraints/Indexes
 	ctx := context.Background()
 	executeSchema(ctx)
@@ -91,6 +110,11 @@ func InitSystem(models ...interface{}) {
     InitializeDatabase(models...)
     InitializeMemgraph()
 
+    // Names for autocomplete; the API still works without them
+    if err := RefreshNameIndex(); err != nil {
+        log.Printf("Autocomplete: %v", err)
+    }
+
     // 2. Perform Parity Check
     log.Println("Checking Database Parity")
     pgCount := GetPostgresCardCount()
@@ -98,21 +122,26 @@ func InitSystem(models ...interface{}) {
 
     fmt.Printf("Counts -> Postgres (Unique Cards): %d | Memgraph (Nodes): %d\n", pgCount, mgCount)
 
-    if pgCount != mgCount || mgCount == 0 {
-        fmt.Println("Out of sync! Background Memgraph re-sync started...")
-        
-        // START GOROUTINE: This prevents blocking the rest of the app
-        go func() {
-            if err := ReSyncToMemgraph(); err != nil {
-                // Use log.Printf instead of Fatalf here so the app doesn't crash 
-                // if only the graph sync fails
-                log.Printf("Background re-sync failed: %v", err)
-            } else {
-                fmt.Println("Background Memgraph re-sync completed successfully!")
-            }
-        }()
-        
+    // An empty graph can't be trusted to match the saved fingerprints, so
+    // rebuild it fully; otherwise only send what changed.
+    syncGraph := IncrementalSyncToMemgraph
+    if mgCount == 0 {
+        fmt.Println("Memgraph is empty! Background Memgraph re-sync started...")
+        syncGraph = ReSyncToMemgraph
+    } else if pgCount != mgCount {
+        fmt.Println("Out of sync! Background Memgraph sync started...")
     } else {
-        fmt.Println("Databases are in sync. Ready to go!")
+        fmt.Println("Counts match. Background check for changed cards started...")
     }
+
+    // START GOROUTINE: This prevents blocking the rest of the app
+    go func() {
+        if err := syncGraph(); err != nil {
+            // Use log.Printf instead of Fatalf here so the app doesn't crash 
+            // if only the graph sync fails
+            log.Printf("Background sync failed: %v", err)
+        } else {
+            fmt.Println("Background Memgraph sync completed successfully!")
+        }
+    }()
 }
\ No newline at end of file
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-backend/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB points DB at a fresh schema in the Postgres named by the
+// TEST_DATABASE_URL DSN and migrates it, skipping the test when it isn't
+// set. The schema is dropped when the test ends.
+func openTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	config := &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	}
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("connecting to test database: %v", err)
+	}
+	// Extensions stay in public, which the test schema's search path keeps
+	if err := admin.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		t.Fatalf("creating pg_trgm: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("creating test schema: %v", err)
+	}
+
+	sep := " "
+	if strings.Contains(dsn, "://") {
+		sep = "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+	}
+	db, err := gorm.Open(postgres.Open(dsn+sep+"search_path="+schema+",public"), config)
+	if err != nil {
+		t.Fatalf("connecting to test schema: %v", err)
+	}
+	previous := DB
+	DB = db
+	t.Cleanup(func() {
+		DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	err = DB.AutoMigrate(
+		&models.Card{}, &models.PrimeCheckpoint{}, &models.Ruling{},
+		&models.BulkImport{}, &models.GraphSyncState{}, &models.OutboxEvent{},
+		&models.Deck{}, &models.DeckEntry{},
+	)
+	if err != nil {
+		t.Fatalf("migrating test schema: %v", err)
+	}
+	if err := migrateFullText(); err != nil {
+		t.Fatal(err)
+	}
+}
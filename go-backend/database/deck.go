@@ -0,0 +1,303 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"go-backend/models"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrDeckNotFound is returned for a deck ID with no (undeleted) deck.
+	ErrDeckNotFound = errors.New("deck not found")
+	// ErrInvalidDeck wraps problems with a deck a client sent.
+	ErrInvalidDeck = errors.New("invalid deck")
+)
+
+// maxDeckEntries caps the distinct entries in one deck; a cube with every
+// board filled stays well under it
+const maxDeckEntries = 1000
+
+// DeckPage is one page of ListDecks, newest deck first.
+type DeckPage struct {
+	Data       []models.Deck `json:"data"`
+	NextCursor string        `json:"next_cursor"` // empty on the last page
+}
+
+// ListDecks pages through saved decks without their entries.
+func ListDecks(limit int, cursor string) (*DeckPage, error) {
+	if limit <= 0 || limit > maxPageLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidPage, maxPageLimit)
+	}
+	q := DB.Order("id DESC").Limit(limit + 1)
+	if cursor != "" {
+		cur, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		after, err := strconv.ParseUint(cur.ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+		}
+		q = q.Where("id < ?", after)
+	}
+
+	page := &DeckPage{Data: []models.Deck{}}
+	if err := q.Find(&page.Data).Error; err != nil {
+		return nil, err
+	}
+	if len(page.Data) > limit {
+		page.Data = page.Data[:limit]
+		last := page.Data[limit-1]
+		page.NextCursor = encodeCursor(pageCursor{ID: strconv.FormatUint(uint64(last.ID), 10)})
+	}
+	return page, nil
+}
+
+// GetDeck loads a deck with its entries, each carrying the card it stands
+// for: the pinned printing, or the newest English printing of the card.
+func GetDeck(id uint) (*models.Deck, error) {
+	var deck models.Deck
+	err := DB.Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&deck, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeckNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := attachDeckCards(deck.Entries); err != nil {
+		return nil, err
+	}
+	return &deck, nil
+}
+
+// CreateDeck validates and saves a new deck with its entries, filling in
+// deck.ID.
+func CreateDeck(deck *models.Deck) (*models.Deck, error) {
+	deck.ID = 0
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := prepareDeck(tx, deck); err != nil {
+			return err
+		}
+		return tx.Create(deck).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetDeck(deck.ID)
+}
+
+// UpdateDeck replaces a deck's name, format, description and entries.
+func UpdateDeck(id uint, deck *models.Deck) (*models.Deck, error) {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.Deck
+		err := tx.Select("id").First(&existing, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDeckNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := prepareDeck(tx, deck); err != nil {
+			return err
+		}
+		err = tx.Model(&existing).Updates(map[string]interface{}{
+			"name":        deck.Name,
+			"format":      deck.Format,
+			"description": deck.Description,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return replaceDeckEntries(tx, id, deck.Entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetDeck(id)
+}
+
+// replaceDeckEntries swaps a deck's entries for entries.
+func replaceDeckEntries(tx *gorm.DB, id uint, entries []models.DeckEntry) error {
+	if err := tx.Where("deck_id = ?", id).Delete(&models.DeckEntry{}).Error; err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	for i := range entries {
+		entries[i].ID, entries[i].DeckID = 0, id
+	}
+	return tx.Create(&entries).Error
+}
+
+// DeleteDeck soft-deletes a deck; its entries stay for the record.
+func DeleteDeck(id uint) error {
+	res := DB.Delete(&models.Deck{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrDeckNotFound
+	}
+	return nil
+}
+
+type entryKey struct {
+	board    models.Board
+	cardID   string
+	oracleID string
+}
+
+// prepareDeck checks a deck a client sent and normalises it: boards and
+// quantities default, every entry gets the oracle ID of the card it names,
+// and repeated entries for the same card and board are merged.
+func prepareDeck(tx *gorm.DB, deck *models.Deck) error {
+	deck.Name = strings.TrimSpace(deck.Name)
+	if deck.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidDeck)
+	}
+	if deck.Format != "" {
+		format, err := models.ParseFormat(string(deck.Format))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDeck, err)
+		}
+		deck.Format = format
+	}
+	if len(deck.Entries) > maxDeckEntries {
+		return fmt.Errorf("%w: more than %d entries", ErrInvalidDeck, maxDeckEntries)
+	}
+
+	var cardIDs, oracleIDs []string
+	for i := range deck.Entries {
+		e := &deck.Entries[i]
+		board, err := models.ParseBoard(string(e.Board))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDeck, err)
+		}
+		e.Board = board
+		switch {
+		case e.Quantity == 0:
+			e.Quantity = 1
+		case e.Quantity < 0:
+			return fmt.Errorf("%w: quantity must be positive", ErrInvalidDeck)
+		}
+		if e.CardID != nil {
+			id := strings.ToLower(strings.TrimSpace(*e.CardID))
+			e.CardID = &id
+			cardIDs = append(cardIDs, id)
+		} else if e.OracleID != "" {
+			oracleIDs = append(oracleIDs, e.OracleID)
+		} else {
+			return fmt.Errorf("%w: entry %d needs a card_id or oracle_id", ErrInvalidDeck, i+1)
+		}
+	}
+
+	// a pinned printing decides the oracle ID
+	printings := make(map[string]string)
+	if len(cardIDs) > 0 {
+		var cards []models.Card
+		if err := tx.Select("id", "oracle_id").Where("id IN ?", cardIDs).Find(&cards).Error; err != nil {
+			return err
+		}
+		for _, c := range cards {
+			if c.OracleID != nil {
+				printings[c.ID] = *c.OracleID
+			}
+		}
+	}
+	known := make(map[string]bool)
+	if len(oracleIDs) > 0 {
+		var found []string
+		err := tx.Model(&models.Card{}).Where("oracle_id IN ?", oracleIDs).
+			Distinct().Pluck("oracle_id", &found).Error
+		if err != nil {
+			return err
+		}
+		for _, id := range found {
+			known[id] = true
+		}
+	}
+
+	merged := make(map[entryKey]int)
+	entries := deck.Entries[:0]
+	for _, e := range deck.Entries {
+		if e.CardID != nil {
+			oracleID, ok := printings[*e.CardID]
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrCardNotFound, *e.CardID)
+			}
+			if e.OracleID != "" && e.OracleID != oracleID {
+				return fmt.Errorf("%w: card %s is not oracle ID %s", ErrInvalidDeck, *e.CardID, e.OracleID)
+			}
+			e.OracleID = oracleID
+		} else if !known[e.OracleID] {
+			return fmt.Errorf("%w: %s", ErrCardNotFound, e.OracleID)
+		}
+
+		key := entryKey{board: e.Board, oracleID: e.OracleID}
+		if e.CardID != nil {
+			key.cardID = *e.CardID
+		}
+		if i, ok := merged[key]; ok {
+			entries[i].Quantity += e.Quantity
+			continue
+		}
+		merged[key] = len(entries)
+		entries = append(entries, e)
+	}
+	deck.Entries = entries
+	return nil
+}
+
+// attachDeckCards fills in each entry's Card.
+func attachDeckCards(entries []models.DeckEntry) error {
+	var cardIDs, oracleIDs []string
+	for _, e := range entries {
+		if e.CardID != nil {
+			cardIDs = append(cardIDs, *e.CardID)
+		} else {
+			oracleIDs = append(oracleIDs, e.OracleID)
+		}
+	}
+
+	var cards []models.Card
+	if len(cardIDs) > 0 {
+		if err := DB.Where("id IN ?", cardIDs).Find(&cards).Error; err != nil {
+			return err
+		}
+	}
+	if len(oracleIDs) > 0 {
+		var newest []models.Card
+		err := DB.Raw(`
+			SELECT DISTINCT ON (oracle_id) * FROM cards
+			WHERE oracle_id IN ? AND lang = 'en' AND deleted_at IS NULL
+			ORDER BY oracle_id, released_at DESC NULLS LAST`, oracleIDs).
+			Scan(&newest).Error
+		if err != nil {
+			return err
+		}
+		cards = append(cards, newest...)
+	}
+
+	byID := make(map[string]*models.Card, len(cards))
+	byOracle := make(map[string]*models.Card, len(cards))
+	for i := range cards {
+		byID[cards[i].ID] = &cards[i]
+		if cards[i].OracleID != nil {
+			byOracle[*cards[i].OracleID] = &cards[i]
+		}
+	}
+	for i := range entries {
+		if entries[i].CardID != nil {
+			entries[i].Card = byID[*entries[i].CardID]
+		} else {
+			entries[i].Card = byOracle[entries[i].OracleID]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"fmt"
+	"go-backend/decklist"
+	"go-backend/models"
+	"sort"
+	"strings"
+)
+
+// Reasons an imported line didn't make it into the deck.
+const (
+	ImportUnreadable = "unreadable"
+	ImportNotFound   = "not_found"
+	ImportAmbiguous  = "ambiguous"
+)
+
+// maxImportSuggestions is how many fuzzy matches a missing card gets
+const maxImportSuggestions = 5
+
+// ImportProblem is a decklist line left out of the imported deck.
+// Suggestions are card names the line may have meant.
+type ImportProblem struct {
+	Line        int      `json:"line"`
+	Text        string   `json:"text"`
+	Reason      string   `json:"reason"`
+	Message     string   `json:"message,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// DeckImport is a parsed decklist resolved against the cards table. Deck is
+// unsaved and can be sent to CreateDeck as is.
+type DeckImport struct {
+	Deck     models.Deck     `json:"deck"`
+	Problems []ImportProblem `json:"problems"`
+}
+
+// ImportDecklist parses text (see package decklist) and looks each line up:
+// a card ID, or a set and collector number, pins that printing; an oracle
+// ID takes any printing; otherwise the name resolves as in findCardsByName,
+// the same lookup CheckLegality uses, so tokens, emblems, art cards and
+// non-English printings never match. Names matching several cards, or
+// none, are reported with suggestions; a format narrows the fuzzy
+// suggestions to cards legal in it. An empty name or format keeps the one
+// the list carried.
+func ImportDecklist(text string, name string, format models.Format) (*DeckImport, error) {
+	list, err := decklist.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDeck, err)
+	}
+	if name == "" {
+		name = list.Name
+	}
+	if format == "" && list.Format != "" {
+		if format, err = models.ParseFormat(list.Format); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDeck, err)
+		}
+	}
+	result := &DeckImport{
+		Deck:     models.Deck{Name: name, Format: format, Entries: []models.DeckEntry{}},
+		Problems: []ImportProblem{},
+	}
+	for _, p := range list.Problems {
+		result.Problems = append(result.Problems, ImportProblem{
+			Line: p.Line, Text: p.Text, Reason: ImportUnreadable, Message: p.Msg,
+		})
+	}
+
+	byID, printings, err := lookupPrintings(list.Entries)
+	if err != nil {
+		return nil, err
+	}
+	oracles, err := lookupOracleIDs(list.Entries)
+	if err != nil {
+		return nil, err
+	}
+	var byName []decklist.Entry
+	for _, e := range list.Entries {
+		c, pinned := byID[strings.ToLower(e.CardID)]
+		if !pinned && !oracles[e.OracleID] {
+			c, pinned = printings[printingKey(e.Set, e.CollectorNumber)]
+			pinned = pinned && nameMatches(c.Name, e.Name)
+		}
+		switch {
+		case pinned:
+			result.Deck.Entries = append(result.Deck.Entries, models.DeckEntry{
+				Board: e.Board, Quantity: e.Quantity, CardID: &c.ID, OracleID: *c.OracleID,
+			})
+		case oracles[e.OracleID]:
+			result.Deck.Entries = append(result.Deck.Entries, models.DeckEntry{
+				Board: e.Board, Quantity: e.Quantity, OracleID: e.OracleID,
+			})
+		default:
+			byName = append(byName, e)
+		}
+	}
+
+	var names []string
+	for _, e := range byName {
+		if e.Name != "" {
+			names = append(names, strings.ToLower(e.Name))
+		}
+	}
+	candidates, err := findCardsByName(names)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range byName {
+		matches := candidates[strings.ToLower(e.Name)]
+		switch len(matches) {
+		case 1:
+			for oracleID := range matches {
+				result.Deck.Entries = append(result.Deck.Entries, models.DeckEntry{
+					Board: e.Board, Quantity: e.Quantity, OracleID: oracleID,
+				})
+			}
+		case 0:
+			problem := ImportProblem{Line: e.Line, Text: e.Text, Reason: ImportNotFound}
+			if e.Name != "" {
+				page, err := SearchCardByNameFuzzy(e.Name, CardFilter{Format: format},
+					PageRequest{Limit: maxImportSuggestions})
+				if err != nil {
+					return nil, err
+				}
+				for _, c := range page.Data {
+					problem.Suggestions = append(problem.Suggestions, c.Name)
+				}
+			}
+			result.Problems = append(result.Problems, problem)
+		default:
+			problem := ImportProblem{Line: e.Line, Text: e.Text, Reason: ImportAmbiguous}
+			for _, c := range matches {
+				problem.Suggestions = append(problem.Suggestions, c.Name)
+			}
+			sort.Strings(problem.Suggestions)
+			result.Problems = append(result.Problems, problem)
+		}
+	}
+	sort.SliceStable(result.Problems, func(i, j int) bool {
+		return result.Problems[i].Line < result.Problems[j].Line
+	})
+
+	if err := attachDeckCards(result.Deck.Entries); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func printingKey(set, number string) string {
+	return set + "/" + number
+}
+
+// nameMatches reports whether a printing is the card a line named, by full
+// name or front face. Set codes differ between clients, so a line's set
+// and number can point at some other card.
+func nameMatches(cardName, lineName string) bool {
+	front, _, _ := strings.Cut(cardName, " // ")
+	return strings.EqualFold(cardName, lineName) || strings.EqualFold(front, lineName)
+}
+
+// lookupPrintings finds the printings entries name by card ID, and by set
+// and collector number.
+func lookupPrintings(entries []decklist.Entry) (map[string]models.Card, map[string]models.Card, error) {
+	var ids []string
+	var pairs [][]interface{}
+	for _, e := range entries {
+		if e.CardID != "" {
+			ids = append(ids, strings.ToLower(e.CardID))
+		}
+		if e.Set != "" && e.CollectorNumber != "" {
+			pairs = append(pairs, []interface{}{e.Set, e.CollectorNumber})
+		}
+	}
+	byID := make(map[string]models.Card)
+	bySet := make(map[string]models.Card)
+	if len(ids)+len(pairs) == 0 {
+		return byID, bySet, nil
+	}
+
+	match := DB.Where("1 = 0")
+	if len(ids) > 0 {
+		match = match.Or("id IN ?", ids)
+	}
+	if len(pairs) > 0 {
+		match = match.Or("(lower(set_code), collector_number) IN ?", pairs)
+	}
+	var cards []models.Card
+	err := DB.Select("id", "name", "oracle_id", "set_code", "collector_number").
+		Where(match).Where("oracle_id IS NOT NULL").
+		Find(&cards).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range cards {
+		byID[c.ID] = c
+		if c.CollectorNumber != nil {
+			bySet[printingKey(strings.ToLower(c.SetCode), *c.CollectorNumber)] = c
+		}
+	}
+	return byID, bySet, nil
+}
+
+// lookupOracleIDs reports which of the entries' oracle IDs exist.
+func lookupOracleIDs(entries []decklist.Entry) (map[string]bool, error) {
+	var ids []string
+	for _, e := range entries {
+		if e.OracleID != "" {
+			ids = append(ids, e.OracleID)
+		}
+	}
+	known := make(map[string]bool)
+	if len(ids) == 0 {
+		return known, nil
+	}
+	var found []string
+	err := DB.Model(&models.Card{}).Where("oracle_id IN ?", ids).
+		Distinct().Pluck("oracle_id", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		known[id] = true
+	}
+	return known, nil
+}
@@ -0,0 +1,21 @@
+package database
+
+import "testing"
+
+// TestImportResolvesNamesLikeLegality checks an imported name finds the
+// same card CheckLegality does, not the art card or token named alike.
+func TestImportResolvesNamesLikeLegality(t *testing.T) {
+	openTestDB(t)
+	createTarmogoyfs(t)
+
+	result, err := ImportDecklist("4 Tarmogoyf\n", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Problems) != 0 {
+		t.Fatalf("problems = %+v", result.Problems)
+	}
+	if len(result.Deck.Entries) != 1 || result.Deck.Entries[0].OracleID != "goyf" {
+		t.Fatalf("entries = %+v, want one of oracle goyf", result.Deck.Entries)
+	}
+}
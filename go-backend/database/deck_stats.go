@@ -0,0 +1,267 @@
+package database
+
+import (
+	"fmt"
+	"go-backend/mechanics"
+	"go-backend/models"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DeckStats summarises the cards a deck plays: its commanders and main
+// deck, counting every copy. Curve, average and pips cover spells only;
+// a double-faced card is a land only if its front face is.
+type DeckStats struct {
+	Cards      int          `json:"cards"`
+	Lands      int          `json:"lands"`
+	Spells     int          `json:"spells"`
+	AverageCMC float64      `json:"average_cmc"`
+	ManaCurve  []FacetCount `json:"mana_curve"` // 0 to 6 then "7+"
+	Pips       []FacetCount `json:"pips"`       // WUBRG order
+	Types      []FacetCount `json:"types"`
+	Mechanics  []FacetCount `json:"mechanics"`
+	LandAdvice LandAdvice   `json:"land_advice"`
+	Price      DeckPrice    `json:"price"`
+	// NotFound lists references no card could be found for
+	NotFound []string `json:"not_found"`
+}
+
+// LandAdvice suggests a land count from deck size, average mana value and
+// cheap draw and ramp spells, after Frank Karsten's regressions for
+// 60-card and Commander decks; other sizes scale the 60-card one. Colors
+// splits the recommendation by each colour's share of the pips.
+type LandAdvice struct {
+	Current     int          `json:"current"`
+	Recommended int          `json:"recommended"`
+	Colors      []FacetCount `json:"colors"`
+}
+
+// DeckPrice totals the non-foil price of every copy. Unpriced counts copies
+// with no USD price, so the USD total is a floor.
+type DeckPrice struct {
+	USD      models.Decimal `json:"usd"`
+	EUR      models.Decimal `json:"eur"`
+	Tix      models.Decimal `json:"tix"`
+	Unpriced int            `json:"unpriced"`
+}
+
+// statCard is a card and how many copies are played.
+type statCard struct {
+	card     *models.Card
+	quantity int
+}
+
+// AnalyzeDeck computes the stats of a saved deck.
+func AnalyzeDeck(id uint) (*DeckStats, error) {
+	deck, err := GetDeck(id)
+	if err != nil {
+		return nil, err
+	}
+	var cards []statCard
+	notFound := []string{}
+	for _, e := range deck.Entries {
+		if e.Board != models.BoardMain && e.Board != models.BoardCommander {
+			continue
+		}
+		if e.Card == nil {
+			notFound = append(notFound, e.OracleID)
+			continue
+		}
+		cards = append(cards, statCard{card: e.Card, quantity: e.Quantity})
+	}
+	stats := analyzeCards(cards)
+	stats.NotFound = notFound
+	return stats, nil
+}
+
+// AnalyzeCards computes the stats of a list of Scryfall IDs, each
+// occurrence being one copy.
+func AnalyzeCards(ids []string) (*DeckStats, error) {
+	counts := make(map[string]int)
+	var unique []string
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if counts[id] == 0 {
+			unique = append(unique, id)
+		}
+		counts[id]++
+	}
+	var found []models.Card
+	if len(unique) > 0 {
+		if err := DB.Where("id IN ?", unique).Find(&found).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[string]*models.Card, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+
+	var cards []statCard
+	notFound := []string{}
+	for _, id := range unique {
+		if c, ok := byID[id]; ok {
+			cards = append(cards, statCard{card: c, quantity: counts[id]})
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+	stats := analyzeCards(cards)
+	stats.NotFound = notFound
+	return stats, nil
+}
+
+func analyzeCards(cards []statCard) *DeckStats {
+	stats := &DeckStats{}
+	curve := make(map[string]int64)
+	pips := make(map[string]int64)
+	types := make(map[string]int64)
+	mechs := make(map[string]int64)
+	var totalCMC float64
+	cheapDrawRamp := 0
+
+	for _, sc := range cards {
+		c, n := sc.card, sc.quantity
+		stats.Cards += n
+
+		faces := c.Faces()
+		front := faces[0].TypeLine
+		front, _, _ = strings.Cut(front, " — ")
+		for _, t := range cardTypes {
+			if slices.Contains(strings.Fields(front), t) {
+				types[t] += int64(n)
+			}
+		}
+
+		var cardMechs []string
+		for _, f := range faces {
+			for _, m := range mechanics.Default().Extract(f.Name, f.OracleText) {
+				if !slices.Contains(cardMechs, m) {
+					cardMechs = append(cardMechs, m)
+				}
+			}
+		}
+		for _, m := range cardMechs {
+			mechs[m] += int64(n)
+		}
+
+		addPrice(&stats.Price, c.Prices, n)
+
+		if slices.Contains(strings.Fields(front), "Land") {
+			stats.Lands += n
+			continue
+		}
+		stats.Spells += n
+		cmc := derefFloat(c.CMC)
+		totalCMC += cmc * float64(n)
+		curve[cmcBucket(cmc)] += int64(n)
+		if cost, err := c.ParsedManaCost(); err == nil {
+			for color, count := range cost.Pips() {
+				pips[color] += int64(count * n)
+			}
+		}
+		if cmc <= 2 && (slices.Contains(cardMechs, "Draw") || slices.Contains(cardMechs, "Ramp")) {
+			cheapDrawRamp += n
+		}
+	}
+
+	if stats.Spells > 0 {
+		stats.AverageCMC = math.Round(totalCMC/float64(stats.Spells)*100) / 100
+	}
+	for i := 0; i <= 7; i++ {
+		bucket := cmcBucket(float64(i))
+		stats.ManaCurve = append(stats.ManaCurve, FacetCount{Value: bucket, Count: curve[bucket]})
+	}
+	stats.Pips = []FacetCount{}
+	for _, color := range models.ColorOrder {
+		if pips[color] > 0 {
+			stats.Pips = append(stats.Pips, FacetCount{Value: color, Count: pips[color]})
+		}
+	}
+	stats.Types = sortedCounts(types)
+	stats.Mechanics = sortedCounts(mechs)
+	stats.LandAdvice = adviseLands(stats, cheapDrawRamp)
+	return stats
+}
+
+// cmcBucket is the mana curve column for a mana value, as in the search
+// facets.
+func cmcBucket(cmc float64) string {
+	if cmc >= 7 {
+		return "7+"
+	}
+	return fmt.Sprint(int(cmc))
+}
+
+func adviseLands(stats *DeckStats, cheapDrawRamp int) LandAdvice {
+	advice := LandAdvice{Current: stats.Lands, Colors: []FacetCount{}}
+	if stats.Cards == 0 {
+		return advice
+	}
+	var lands float64
+	if stats.Cards >= 90 {
+		lands = 31.42 + 3.13*stats.AverageCMC - 0.28*float64(cheapDrawRamp)
+	} else {
+		lands = (19.59 + 1.90*stats.AverageCMC - 0.28*float64(cheapDrawRamp)) * float64(stats.Cards) / 60
+	}
+	advice.Recommended = int(math.Round(math.Max(0, math.Min(lands, float64(stats.Cards)))))
+
+	var total int64
+	for _, p := range stats.Pips {
+		total += p.Count
+	}
+	// largest remainders, so the colours add up to the recommendation
+	remainders := make([]float64, len(stats.Pips))
+	left := int64(advice.Recommended)
+	for i, p := range stats.Pips {
+		share := float64(advice.Recommended) * float64(p.Count) / float64(total)
+		whole := math.Floor(share)
+		remainders[i] = share - whole
+		left -= int64(whole)
+		advice.Colors = append(advice.Colors, FacetCount{Value: p.Value, Count: int64(whole)})
+	}
+	order := make([]int, len(remainders))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for _, i := range order[:min(int(left), len(order))] {
+		advice.Colors[i].Count++
+	}
+	return advice
+}
+
+// addPrice adds n copies at the card's non-foil prices.
+func addPrice(total *DeckPrice, prices *models.Prices, n int) {
+	if prices == nil || prices.USD == nil {
+		total.Unpriced += n
+	}
+	if prices == nil {
+		return
+	}
+	for _, p := range []struct {
+		sum   *models.Decimal
+		price *models.Decimal
+	}{{&total.USD, prices.USD}, {&total.EUR, prices.EUR}, {&total.Tix, prices.Tix}} {
+		if p.price != nil {
+			*p.sum += *p.price * models.Decimal(n)
+		}
+	}
+}
+
+// sortedCounts orders a tally largest first, then by value.
+func sortedCounts(counts map[string]int64) []FacetCount {
+	out := make([]FacetCount, 0, len(counts))
+	for v, n := range counts {
+		out = append(out, FacetCount{Value: v, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
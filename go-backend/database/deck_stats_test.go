@@ -0,0 +1,206 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"go-backend/models"
+)
+
+// statsCard builds a card for analyzeCards; an empty cost or text is left
+// nil as Scryfall leaves it.
+func statsCard(name, typeLine, cost string, cmc float64, text string) *models.Card {
+	c := &models.Card{Name: name, TypeLine: typeLine, CMC: &cmc}
+	if cost != "" {
+		c.ManaCost = &cost
+	}
+	if text != "" {
+		c.OracleText = &text
+	}
+	return c
+}
+
+// withFaces turns c into a multi-faced card. A double-faced card has no
+// top-level cost, so pass one only for split cards.
+func withFaces(c *models.Card, cost string, faces ...models.CardFace) *models.Card {
+	c.ManaCost = nil
+	if cost != "" {
+		c.ManaCost = &cost
+	}
+	c.CardFaces = faces
+	return c
+}
+
+func withPrices(c *models.Card, usd, eur, tix models.Decimal) *models.Card {
+	price := func(d models.Decimal) *models.Decimal {
+		if d == 0 {
+			return nil
+		}
+		return &d
+	}
+	c.Prices = &models.Prices{USD: price(usd), EUR: price(eur), Tix: price(tix)}
+	return c
+}
+
+func curve(counts ...int64) []FacetCount {
+	out := make([]FacetCount, len(counts))
+	for i, n := range counts {
+		out[i] = FacetCount{Value: cmcBucket(float64(i)), Count: n}
+	}
+	return out
+}
+
+func TestAnalyzeCards(t *testing.T) {
+	tests := []struct {
+		name  string
+		cards []statCard
+		want  *DeckStats
+	}{
+		{
+			// 60 cards: 26 lands, 34 spells of mana value 88
+			name: "60-card Izzet",
+			cards: []statCard{
+				{withPrices(statsCard("Mountain", "Basic Land — Mountain", "", 0, ""), 5, 0, 0), 14},
+				{statsCard("Island", "Basic Land — Island", "", 0, ""), 10},
+				// a land creature is a land
+				{statsCard("Dryad Arbor", "Land Creature — Forest Dryad", "", 0, ""), 2},
+				{withPrices(statsCard("Lightning Bolt", "Instant", "{R}", 1,
+					"Lightning Bolt deals 3 damage to any target."), 199, 150, 3), 4},
+				{withPrices(statsCard("Opt", "Instant", "{U}", 1, "Scry 1.\nDraw a card."), 10, 0, 0), 4},
+				// priced in euros only: still unpriced
+				{withPrices(statsCard("Counterspell", "Instant", "{U}{U}", 2, "Counter target spell."), 0, 40, 0), 4},
+				{withFaces(statsCard("Fire // Ice", "Instant // Instant", "", 4, ""), "{1}{R} // {1}{U}",
+					models.CardFace{Name: "Fire", ManaCost: "{1}{R}", TypeLine: "Instant"},
+					models.CardFace{Name: "Ice", ManaCost: "{1}{U}", TypeLine: "Instant"}), 4},
+				{withFaces(statsCard("Delver of Secrets // Insectile Aberration",
+					"Creature — Human Wizard // Creature — Human Insect", "", 1, ""), "",
+					models.CardFace{Name: "Delver of Secrets", ManaCost: "{U}", TypeLine: "Creature — Human Wizard"},
+					models.CardFace{Name: "Insectile Aberration", TypeLine: "Creature — Human Insect", OracleText: "Flying"}), 4},
+				{statsCard("Shivan Dragon", "Creature — Dragon", "{4}{R}{R}", 6, "Flying"), 2},
+				// a land on its back is still a spell
+				{withFaces(statsCard("Sea Gate Restoration // Sea Gate, Reborn", "Sorcery // Land", "", 7, ""), "",
+					models.CardFace{Name: "Sea Gate Restoration", ManaCost: "{4}{U}{U}{U}", TypeLine: "Sorcery"},
+					models.CardFace{Name: "Sea Gate, Reborn", TypeLine: "Land"}), 2},
+				{statsCard("Izzet Signet", "Artifact", "{2}", 2, "{1}, {T}: Add {U}{R}."), 4},
+				{statsCard("Thing in the Ice", "Creature — Horror", "{1}{U}", 2, ""), 4},
+				{statsCard("Ral, Izzet Viceroy", "Legendary Planeswalker — Ral", "{3}{U}{R}", 5, ""), 2},
+			},
+			want: &DeckStats{
+				Cards: 60, Lands: 26, Spells: 34,
+				AverageCMC: 2.59,
+				ManaCurve:  curve(0, 12, 12, 0, 4, 2, 2, 2),
+				Pips:       []FacetCount{{"U", 32}, {"R", 14}},
+				Types: []FacetCount{
+					{"Land", 26}, {"Instant", 16}, {"Creature", 12},
+					{"Artifact", 4}, {"Planeswalker", 2}, {"Sorcery", 2},
+				},
+				Mechanics: []FacetCount{
+					{"Burn", 4}, {"Counterspell", 4}, {"Draw", 4}, {"Ramp", 4}, {"Removal", 4}, {"Selection", 4},
+				},
+				// 19.59 + 1.90*2.59 - 0.28*8 cheap draw and ramp = 22.27; the
+				// 46 pips split 15.30 blue, 6.70 red and red takes the spare
+				LandAdvice: LandAdvice{Current: 26, Recommended: 22, Colors: []FacetCount{{"U", 15}, {"R", 7}}},
+				Price:      DeckPrice{USD: 906, EUR: 760, Tix: 12, Unpriced: 38},
+			},
+		},
+		{
+			// 40 cards: 17 lands, 23 spells of mana value 99
+			name: "40-card limited with hybrid and Phyrexian pips",
+			cards: []statCard{
+				{statsCard("Forest", "Basic Land — Forest", "", 0, ""), 9},
+				{statsCard("Plains", "Basic Land — Plains", "", 0, ""), 8},
+				{statsCard("Llanowar Elves", "Creature — Elf Druid", "{G}", 1, "{T}: Add {G}."), 2},
+				{statsCard("Mutagenic Growth", "Instant", "{G/P}", 1, ""), 1},
+				{statsCard("Grizzly Bears", "Creature — Bear", "{1}{G}", 2, ""), 4},
+				{statsCard("Pacifism", "Enchantment — Aura", "{1}{W}", 2, ""), 2},
+				{statsCard("Kitchen Finks", "Creature — Ouphe", "{1}{G/W}{G/W}", 3, ""), 3},
+				{statsCard("Serra Angel", "Creature — Angel", "{3}{W}{W}", 5, "Flying, vigilance"), 3},
+				{statsCard("Craw Wurm", "Creature — Wurm", "{4}{G}{G}", 6, ""), 6},
+				{statsCard("Ghalta, Primal Hunger", "Legendary Creature — Dinosaur", "{10}{G}{G}", 12, ""), 2},
+			},
+			want: &DeckStats{
+				Cards: 40, Lands: 17, Spells: 23,
+				AverageCMC: 4.30,
+				ManaCurve:  curve(0, 3, 6, 3, 0, 3, 6, 2),
+				Pips:       []FacetCount{{"W", 14}, {"G", 29}},
+				Types:      []FacetCount{{"Creature", 20}, {"Land", 17}, {"Enchantment", 2}, {"Instant", 1}},
+				Mechanics:  []FacetCount{{"Ramp", 2}},
+				// (19.59 + 1.90*4.30 - 0.28*2) * 40/60 = 18.13; the 43 pips
+				// split 5.86 white, 12.14 green and white takes the spare
+				LandAdvice: LandAdvice{Current: 17, Recommended: 18, Colors: []FacetCount{{"W", 6}, {"G", 12}}},
+				Price:      DeckPrice{Unpriced: 40},
+			},
+		},
+		{
+			// 100 cards: 36 lands, 64 spells of mana value 189
+			name: "100-card Commander",
+			cards: []statCard{
+				{statsCard("Forest", "Basic Land — Forest", "", 0, ""), 36},
+				{withPrices(statsCard("Sol Ring", "Artifact", "{1}", 1, "{T}: Add {C}{C}."), 150, 0, 50), 1},
+				{statsCard("Elvish Mystic", "Creature — Elf Druid", "{G}", 1, "{T}: Add {G}."), 1},
+				{statsCard("Cultivate", "Sorcery", "{2}{G}", 3,
+					"Search your library for up to two basic land cards, reveal those cards, put one onto the battlefield tapped and the other into your hand, then shuffle."), 1},
+				{statsCard("Harmonize", "Sorcery", "{2}{G}{G}", 4, "Draw three cards."), 1},
+				{statsCard("Grizzly Bears", "Creature — Bear", "{1}{G}", 2, ""), 30},
+				{statsCard("Troll Ascetic", "Creature — Troll Shaman", "{2}{G}{G}", 4, ""), 30},
+			},
+			want: &DeckStats{
+				Cards: 100, Lands: 36, Spells: 64,
+				AverageCMC: 2.95,
+				ManaCurve:  curve(0, 2, 30, 1, 31, 0, 0, 0),
+				Pips:       []FacetCount{{"G", 94}},
+				Types:      []FacetCount{{"Creature", 61}, {"Land", 36}, {"Sorcery", 2}, {"Artifact", 1}},
+				Mechanics:  []FacetCount{{"Ramp", 3}, {"Draw", 1}},
+				// 31.42 + 3.13*2.95 - 0.28*2 = 40.09
+				LandAdvice: LandAdvice{Current: 36, Recommended: 40, Colors: []FacetCount{{"G", 40}}},
+				Price:      DeckPrice{USD: 150, Tix: 50, Unpriced: 99},
+			},
+		},
+		{
+			name: "colourless, with X and a 7+ spell",
+			cards: []statCard{
+				{statsCard("Wastes", "Basic Land — Wastes", "", 0, ""), 4},
+				{statsCard("Ornithopter", "Artifact Creature — Thopter", "{0}", 0, "Flying"), 4},
+				{statsCard("Walking Ballista", "Artifact Creature — Construct", "{X}{X}", 0, ""), 2},
+				{statsCard("Kozilek, Butcher of Truth", "Legendary Creature — Eldrazi", "{10}", 10, ""), 1},
+			},
+			want: &DeckStats{
+				Cards: 11, Lands: 4, Spells: 7,
+				AverageCMC: 1.43,
+				ManaCurve:  curve(6, 0, 0, 0, 0, 0, 0, 1),
+				Pips:       []FacetCount{},
+				Types:      []FacetCount{{"Creature", 7}, {"Artifact", 6}, {"Land", 4}},
+				Mechanics:  []FacetCount{},
+				// (19.59 + 1.90*1.43) * 11/60 = 4.09, with no colour to split
+				LandAdvice: LandAdvice{Current: 4, Recommended: 4, Colors: []FacetCount{}},
+				Price:      DeckPrice{Unpriced: 11},
+			},
+		},
+		{
+			name: "empty",
+			want: &DeckStats{
+				ManaCurve:  curve(0, 0, 0, 0, 0, 0, 0, 0),
+				Pips:       []FacetCount{},
+				Types:      []FacetCount{},
+				Mechanics:  []FacetCount{},
+				LandAdvice: LandAdvice{Colors: []FacetCount{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := analyzeCards(tt.cards)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCMCBucket(t *testing.T) {
+	for cmc, want := range map[float64]string{0: "0", 0.5: "0", 1: "1", 6: "6", 6.5: "6", 7: "7+", 16: "7+", 1000000: "7+"} {
+		if got := cmcBucket(cmc); got != want {
+			t.Errorf("cmcBucket(%v) = %q, want %q", cmc, got, want)
+		}
+	}
+}
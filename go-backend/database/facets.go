@@ -0,0 +1,94 @@
+package database
+
+import (
+	"sort"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// FacetCount is how many matching cards have one value of a facet.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// SearchFacets summarises every card a search matched, not just the page
+// returned, so filters built from it stay accurate. Colourless cards count
+// as "C" under colors and color_identity; cmc buckets run 0 to 6 then "7+".
+type SearchFacets struct {
+	Colors        []FacetCount `json:"colors"`
+	ColorIdentity []FacetCount `json:"color_identity"`
+	Types         []FacetCount `json:"types"`
+	Rarity        []FacetCount `json:"rarity"`
+	Sets          []FacetCount `json:"sets"`
+	CMC           []FacetCount `json:"cmc"`
+	Formats       []FacetCount `json:"formats"` // formats the card is legal in
+}
+
+// cardTypes are the types counted from TypeLine; supertypes and subtypes
+// are left out.
+var cardTypes = []string{
+	"Artifact", "Battle", "Creature", "Enchantment", "Instant",
+	"Kindred", "Land", "Planeswalker", "Sorcery",
+}
+
+// searchFacets counts the facets of base, a SELECT over card rows, in a
+// single round trip.
+func searchFacets(db *gorm.DB, base string, args []interface{}) (*SearchFacets, error) {
+	query := `
+		WITH matched AS MATERIALIZED (` + base + `)
+		SELECT 'colors' AS facet, v AS value, count(*) AS count
+		FROM matched, unnest(CASE WHEN cardinality(colors) > 0 THEN colors ELSE ARRAY['C'] END) AS v
+		GROUP BY v
+		UNION ALL
+		SELECT 'color_identity', v, count(*)
+		FROM matched, unnest(CASE WHEN cardinality(color_identity) > 0 THEN color_identity ELSE ARRAY['C'] END) AS v
+		GROUP BY v
+		UNION ALL
+		SELECT 'types', t, count(*)
+		FROM matched, unnest(CAST(? AS text[])) AS t
+		WHERE type_line ~ ('\m' || t || '\M')
+		GROUP BY t
+		UNION ALL
+		SELECT 'rarity', rarity, count(*) FROM matched GROUP BY rarity
+		UNION ALL
+		SELECT 'sets', set_code, count(*) FROM matched GROUP BY set_code
+		UNION ALL
+		SELECT 'cmc', CASE WHEN COALESCE(cmc, 0) >= 7 THEN '7+' ELSE floor(COALESCE(cmc, 0))::int::text END AS bucket, count(*)
+		FROM matched
+		GROUP BY bucket
+		UNION ALL
+		SELECT 'formats', l.format, count(*)
+		FROM matched, jsonb_each_text(legalities) AS l(format, status)
+		WHERE l.status = 'legal'
+		GROUP BY l.format
+		ORDER BY facet, count DESC, value`
+
+	var rows []struct {
+		Facet string
+		Value string
+		Count int64
+	}
+	queryArgs := append(append([]interface{}{}, args...), pq.StringArray(cardTypes))
+	if err := db.Raw(query, queryArgs...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	f := &SearchFacets{
+		Colors: []FacetCount{}, ColorIdentity: []FacetCount{}, Types: []FacetCount{},
+		Rarity: []FacetCount{}, Sets: []FacetCount{}, CMC: []FacetCount{}, Formats: []FacetCount{},
+	}
+	facets := map[string]*[]FacetCount{
+		"colors": &f.Colors, "color_identity": &f.ColorIdentity, "types": &f.Types,
+		"rarity": &f.Rarity, "sets": &f.Sets, "cmc": &f.CMC, "formats": &f.Formats,
+	}
+	for _, r := range rows {
+		if list, ok := facets[r.Facet]; ok {
+			*list = append(*list, FacetCount{Value: r.Value, Count: r.Count})
+		}
+	}
+	// buckets read better in mana value order than by count; "7+" sorts last
+	sort.Slice(f.CMC, func(i, j int) bool { return f.CMC[i].Value < f.CMC[j].Value })
+	return f, nil
+}
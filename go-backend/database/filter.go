@@ -0,0 +1,87 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-backend/models"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrCardNotFound is returned when a card a request refers to doesn't exist.
+var ErrCardNotFound = errors.New("card not found")
+
+// CardFilter narrows what a search or suggestion endpoint returns. The zero
+// value filters nothing.
+type CardFilter struct {
+	// Format keeps only cards playable in it: legal, or restricted to one copy
+	Format models.Format
+	// Identity keeps only cards whose colour identity is within it, as for
+	// a commander deck. nil means no constraint; empty means colorless only.
+	Identity []string
+}
+
+// where returns a condition to AND onto a query over card rows, with its
+// args, or "" when there is nothing to filter. Containment checks keep it
+// on the GIN index over legalities.
+func (f CardFilter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.Format != "" {
+		legal, _ := json.Marshal(models.Legalities{f.Format: models.Legal})
+		restricted, _ := json.Marshal(models.Legalities{f.Format: models.Restricted})
+		conds = append(conds, "(legalities @> CAST(? AS jsonb) OR legalities @> CAST(? AS jsonb))")
+		args = append(args, string(legal), string(restricted))
+	}
+	if f.Identity != nil {
+		conds = append(conds, "COALESCE(color_identity, '{}') <@ CAST(? AS text[])")
+		args = append(args, pq.StringArray(f.Identity))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// ParseIdentity reads a colour identity written as WUBRG letters in any
+// order and case, such as "gu", or "c" for colorless.
+func ParseIdentity(s string) ([]string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "C" || s == "COLORLESS" {
+		return []string{}, nil
+	}
+	var colors []string
+	for _, r := range s {
+		c := string(r)
+		if !slices.Contains(models.ColorOrder, c) {
+			return nil, fmt.Errorf("invalid color identity %q", s)
+		}
+		colors = append(colors, c)
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("invalid color identity %q", s)
+	}
+	return colors, nil
+}
+
+// CommanderIdentity is the colour identity of a commander given by Scryfall
+// ID or name. It returns ErrCardNotFound if there is no such card.
+func CommanderIdentity(ref string) ([]string, error) {
+	cards, _, err := resolveCardRefs([]string{ref})
+	if err != nil {
+		return nil, err
+	}
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrCardNotFound, ref)
+	}
+	return append([]string{}, cards[0].ColorIdentity...), nil
+}
+
+// and appends the filter's condition to a WHERE clause and its args.
+func (f CardFilter) and(where string, args []interface{}) (string, []interface{}) {
+	cond, condArgs := f.where()
+	if cond == "" {
+		return where, args
+	}
+	return where + " AND " + cond, append(append([]interface{}{}, args...), condArgs...)
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"fmt"
+	"go-backend/search"
+)
+
+// cardSearchVector weights the name over the type line over rules text.
+// Multi-faced cards keep their rules text on the faces, so that is folded
+// in too. Postgres only accepts immutable expressions here, hence the fixed
+// 'english' config and jsonb_path_query_array rather than a subquery.
+const cardSearchVector = `
+	setweight(to_tsvector('english'::regconfig, coalesce(name, '')), 'A') ||
+	setweight(to_tsvector('english'::regconfig, coalesce(type_line, '')), 'B') ||
+	setweight(to_tsvector('english'::regconfig,
+		coalesce(oracle_text, '') || ' ' ||
+		coalesce(replace(jsonb_path_query_array(card_faces, '$[*].oracle_text')::text, '\n', ' '), '')), 'C')`
+
+// migrateFullText adds the generated search_vector column and its GIN
+// index, which AutoMigrate can't express. Both steps are idempotent.
+func migrateFullText() error {
+	statements := []string{
+		`ALTER TABLE cards ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (` + cardSearchVector + `) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_cards_search_vector ON cards USING GIN (search_vector)`,
+	}
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("full-text migration failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// SearchOracleFullText matches a full-text query (see search.TSQuery) against
+// card names, type lines and rules text, best ts_rank first by default. One
+// English printing per card is returned, the newest.
+func SearchOracleFullText(query string, filter CardFilter, req PageRequest) (*CardPage, error) {
+	tsquery, err := search.TSQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	where, args := filter.and("c.search_vector @@ q.query AND c.lang = 'en' AND c.deleted_at IS NULL",
+		[]interface{}{tsquery})
+	base := `
+		SELECT DISTINCT ON (COALESCE(c.oracle_id, c.id)) c.*, ts_rank(c.search_vector, q.query) AS rank
+		FROM cards c, to_tsquery('english', ?) AS q(query)
+		WHERE ` + where + `
+		ORDER BY COALESCE(c.oracle_id, c.id), c.released_at DESC`
+	return pageCards(DB, base, args, req,
+		SortRank, SortRank, SortName, SortCMC, SortReleased, SortPrice)
+}
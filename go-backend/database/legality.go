@@ -0,0 +1,179 @@
+package database
+
+import (
+	"go-backend/models"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LegalityReport lists, per format, the cards that keep a list of cards
+// out of it. Cards that couldn't be found are listed as given.
+type LegalityReport struct {
+	Formats  map[models.Format]*FormatLegality `json:"formats"`
+	NotFound []string                          `json:"not_found"`
+}
+
+// FormatLegality is one format's verdict. Without quantities a restricted
+// card doesn't make the list illegal; it's reported so callers can check
+// there is only one copy.
+type FormatLegality struct {
+	Legal      bool     `json:"legal"`
+	Banned     []string `json:"banned"`
+	Restricted []string `json:"restricted"`
+	NotLegal   []string `json:"not_legal"`
+}
+
+var scryfallID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// CheckLegality looks each reference up by Scryfall ID or by name (either
+// the full name or a double-faced card's front face, ignoring case) and
+// reports its status in each format. An empty formats checks them all.
+func CheckLegality(refs []string, formats []models.Format) (*LegalityReport, error) {
+	if len(formats) == 0 {
+		formats = models.Formats
+	}
+	cards, notFound, err := resolveCardRefs(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LegalityReport{Formats: make(map[models.Format]*FormatLegality), NotFound: notFound}
+	for _, f := range formats {
+		fl := &FormatLegality{Banned: []string{}, Restricted: []string{}, NotLegal: []string{}}
+		for _, c := range cards {
+			switch c.Legalities[f] {
+			case models.Legal:
+			case models.Restricted:
+				fl.Restricted = append(fl.Restricted, c.Name)
+			case models.Banned:
+				fl.Banned = append(fl.Banned, c.Name)
+			default:
+				fl.NotLegal = append(fl.NotLegal, c.Name)
+			}
+		}
+		fl.Legal = len(fl.Banned) == 0 && len(fl.NotLegal) == 0
+		report.Formats[f] = fl
+	}
+	return report, nil
+}
+
+// resolveCardRefs finds one card per distinct reference, in the order given.
+// Printings of a card share legalities, so any printing will do. Names
+// resolve as in findCardsByName; the rare name still shared by two cards
+// takes the first by oracle ID.
+func resolveCardRefs(refs []string) ([]models.Card, []string, error) {
+	var ids, names []string
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if scryfallID.MatchString(ref) {
+			ids = append(ids, strings.ToLower(ref))
+		} else if ref != "" {
+			names = append(names, strings.ToLower(ref))
+		}
+	}
+	if len(ids)+len(names) == 0 {
+		return nil, []string{}, nil
+	}
+
+	byKey := make(map[string]models.Card)
+	if len(ids) > 0 {
+		var found []models.Card
+		err := DB.Select(namedCardColumns).Where("id IN ?", ids).Find(&found).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, c := range found {
+			byKey[c.ID] = c
+		}
+	}
+	byName, err := findCardsByName(names)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, candidates := range byName {
+		oracleIDs := make([]string, 0, len(candidates))
+		for id := range candidates {
+			oracleIDs = append(oracleIDs, id)
+		}
+		sort.Strings(oracleIDs)
+		byKey[name] = candidates[oracleIDs[0]]
+	}
+
+	cards := []models.Card{}
+	notFound := []string{}
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		c, ok := byKey[strings.ToLower(strings.TrimSpace(ref))]
+		if !ok {
+			notFound = append(notFound, ref)
+			continue
+		}
+		key := c.Name
+		if c.OracleID != nil {
+			key = *c.OracleID
+		}
+		if !seen[key] {
+			seen[key] = true
+			cards = append(cards, c)
+		}
+	}
+	return cards, notFound, nil
+}
+
+// namedCardColumns are what name and reference lookups load of a card
+const namedCardColumns = "id, oracle_id, name, color_identity, legalities"
+
+// namedCardFilter keeps the cards a name can mean: English, and not tokens,
+// emblems or art-series cards ("Card // Card"), which share real cards'
+// names but are legal nowhere.
+const namedCardFilter = `oracle_id IS NOT NULL AND lang = 'en'
+	AND type_line NOT ILIKE '%Token%'
+	AND type_line NOT ILIKE '%Emblem%'
+	AND type_line NOT LIKE 'Card%'`
+
+// findCardsByName maps each lower-cased name to the cards it could mean,
+// by oracle ID, matching the full name or a multi-faced card's front face
+// and ignoring case. A card whose full name matches beats ones that only
+// match by front face. Each card is its newest printing; names matching
+// nothing are left out.
+func findCardsByName(names []string) (map[string]map[string]models.Card, error) {
+	matches := make(map[string]map[string]models.Card)
+	if len(names) == 0 {
+		return matches, nil
+	}
+
+	var cards []models.Card
+	err := DB.Select("DISTINCT ON (oracle_id) "+namedCardColumns).
+		Where("lower(name) IN ? OR lower(split_part(name, ' // ', 1)) IN ?", names, names).
+		Where(namedCardFilter).
+		Order("oracle_id, released_at DESC NULLS LAST").
+		Find(&cards).Error
+	if err != nil {
+		return nil, err
+	}
+
+	exact := make(map[string]map[string]models.Card)
+	front := make(map[string]map[string]models.Card)
+	add := func(m map[string]map[string]models.Card, key string, c models.Card) {
+		if m[key] == nil {
+			m[key] = make(map[string]models.Card)
+		}
+		m[key][*c.OracleID] = c
+	}
+	for _, c := range cards {
+		full := strings.ToLower(c.Name)
+		add(exact, full, c)
+		if f, _, ok := strings.Cut(full, " // "); ok {
+			add(front, f, c)
+		}
+	}
+	for _, n := range names {
+		if m, ok := exact[n]; ok {
+			matches[n] = m
+		} else if m, ok := front[n]; ok {
+			matches[n] = m
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"go-backend/models"
+)
+
+const modern = models.Format("modern")
+
+// createTarmogoyfs saves Tarmogoyf alongside an art card, a token and a
+// German printing that share its name.
+func createTarmogoyfs(t *testing.T) {
+	t.Helper()
+	str := func(s string) *string { return &s }
+	cards := []models.Card{
+		{
+			ID: "a0000000-0000-0000-0000-000000000001", OracleID: str("goyf"), Name: "Tarmogoyf",
+			TypeLine: "Creature — Lhurgoyf", ColorIdentity: []string{"G"}, SetCode: "fut", Rarity: "rare", Lang: "en",
+			Legalities: models.Legalities{modern: models.Legal},
+		},
+		{
+			ID: "a0000000-0000-0000-0000-000000000002", OracleID: str("goyf-art"), Name: "Tarmogoyf // Tarmogoyf",
+			TypeLine: "Card // Card", SetCode: "amh2", Rarity: "common", Lang: "en",
+			Legalities: models.Legalities{modern: models.NotLegal},
+		},
+		{
+			ID: "a0000000-0000-0000-0000-000000000003", OracleID: str("goyf-token"), Name: "Tarmogoyf",
+			TypeLine: "Token Creature — Lhurgoyf", SetCode: "tfut", Rarity: "common", Lang: "en",
+			Legalities: models.Legalities{modern: models.NotLegal},
+		},
+		{
+			ID: "a0000000-0000-0000-0000-000000000004", OracleID: str("goyf"), Name: "Tarmogoyf",
+			TypeLine: "Creature — Lhurgoyf", ColorIdentity: []string{"G"}, SetCode: "fut", Rarity: "rare", Lang: "de",
+			Legalities: models.Legalities{modern: models.Legal},
+		},
+	}
+	if err := DB.Create(&cards).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestNamesSkipArtAndTokenCards checks a name resolves to the real card
+// even when art-series and token printings share it.
+func TestNamesSkipArtAndTokenCards(t *testing.T) {
+	openTestDB(t)
+	createTarmogoyfs(t)
+
+	report, err := CheckLegality([]string{"tarmogoyf"}, []models.Format{modern})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict := report.Formats[modern]; !verdict.Legal {
+		t.Errorf("Tarmogoyf not legal in modern: %+v", verdict)
+	}
+
+	identity, err := CommanderIdentity("Tarmogoyf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(identity, []string{"G"}) {
+		t.Errorf("identity = %v, want [G]", identity)
+	}
+}
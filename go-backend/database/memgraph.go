@@ -2,8 +2,14 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"go-backend/mechanics"
 	"go-backend/models"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
@@ -26,44 +32,228 @@ func derefFloat(f *float64) float64 {
     return *f
 }
 
-func GetCardSuggestions(oracleID string) ([]models.Card, error) {
+// suggestionKeys are the Cypher sort keys suggestions can page on.
+// Similarity is the weighted score described in similarity.go.
+var suggestionKeys = map[SortField]string{
+	SortSimilarity: "score",
+	SortName:       "rec.name",
+	SortCMC:        "coalesce(rec.cmc, 0.0)",
+}
+
+// suggestionQuery is what differs between suggesting for one card and for
+// a whole deck. match binds rec to every candidate (pageSuggestions adds the
+// filters to its WHERE); score carries on from it and must end by binding
+// rec, shared, score and the columns explain reads.
+type suggestionQuery struct {
+	match   string
+	score   string
+	columns string
+	params  map[string]interface{}
+	explain func(score float64, shared []interface{}, record *neo4j.Record) *SimilarityExplanation
+}
+
+// GetCardSuggestions pages through the cards most similar to oracleID,
+// keyset-paginated in Cypher like pageCards. The page's Explanations list
+// what each suggestion shares with the source and what it scored for it.
+func GetCardSuggestions(oracleID string, filter CardFilter, req PageRequest) (*CardPage, error) {
+	return pageSuggestions(suggestionQuery{
+		match: `
+			MATCH (source:Card {id: $id})-[sr:PRODUCES|HAS_KEYWORD|IS_TYPE]->(attr)
+			MATCH (rec:Card)-[:PRODUCES|HAS_KEYWORD|IS_TYPE]->(attr)
+			WHERE rec.id <> source.id`,
+		score: `
+			WITH source, rec, attr, type(sr) AS rel
+			WITH source, rec, attr, rel, ` + attributeWeight + ` AS weight
+			WITH source, rec,
+				collect({kind: rel, name: attr.name, weight: weight}) AS shared,
+				sum(weight) AS attrScore
+			WITH rec, shared,
+				$cmcWeight / (1.0 + abs(coalesce(rec.cmc, 0.0) - coalesce(source.cmc, 0.0))) AS cmcScore,
+				attrScore
+			WITH rec, shared, cmcScore, coalesce(rec.cmc, 0.0) AS cmc,
+				round((attrScore + cmcScore) * 10000) / 10000.0 AS score`,
+		columns: "cmcScore, cmc",
+		params:  map[string]interface{}{"id": oracleID, "cmcWeight": cmcWeight},
+		explain: func(score float64, shared []interface{}, record *neo4j.Record) *SimilarityExplanation {
+			cmcScore, _ := record.Get("cmcScore")
+			cmc, _ := record.Get("cmc")
+			c, _ := cmcScore.(float64)
+			mv, _ := cmc.(float64)
+			return explainSuggestion(score, shared, SimilarityReason{
+				Kind:         "cmc",
+				Name:         "mana value " + strconv.FormatFloat(mv, 'f', -1, 64),
+				Contribution: scoreRound(c),
+			})
+		},
+	}, filter, req)
+}
+
+// GetDeckSuggestions pages through cards for a deck built from oracleIDs:
+// every attribute the deck has counts once per deck card that has it, so
+// cards tying into many of the deck's mechanics and types come first.
+// Cards already in the deck are never suggested.
+func GetDeckSuggestions(oracleIDs []string, filter CardFilter, req PageRequest) (*CardPage, error) {
+	return pageSuggestions(suggestionQuery{
+		match: `
+			MATCH (source:Card)-[sr:PRODUCES|HAS_KEYWORD|IS_TYPE]->(attr)
+			WHERE source.id IN $ids
+			WITH attr, type(sr) AS rel, collect(DISTINCT source.id) AS via
+			MATCH (rec:Card)-[:PRODUCES|HAS_KEYWORD|IS_TYPE]->(attr)
+			WHERE NOT rec.id IN $ids`,
+		score: `
+			WITH rec, attr, rel, via, ` + attributeWeight + ` * size(via) AS weight
+			WITH rec,
+				collect({kind: rel, name: attr.name, weight: weight}) AS shared,
+				sum(weight) AS attrScore,
+				collect(via) AS vias
+			WITH rec, shared, round(attrScore * 10000) / 10000.0 AS score,
+				size(reduce(acc = [], v IN vias | acc + [x IN v WHERE NOT x IN acc])) AS connections`,
+		columns: "connections",
+		params:  map[string]interface{}{"ids": oracleIDs},
+		explain: func(score float64, shared []interface{}, record *neo4j.Record) *SimilarityExplanation {
+			ex := explainSuggestion(score, shared)
+			if n, ok := record.Get("connections"); ok {
+				connections, _ := n.(int64)
+				ex.Connections = int(connections)
+			}
+			return ex
+		},
+	}, filter, req)
+}
+
+// pageSuggestions runs q with the shared filters, sorts and cursor, and
+// fetches the suggested cards from Postgres in the graph's order.
+func pageSuggestions(q suggestionQuery, filter CardFilter, req PageRequest) (*CardPage, error) {
+	desc, cur, err := req.resolve(SortSimilarity, SortSimilarity, SortName, SortCMC)
+	if err != nil {
+		return nil, err
+	}
+	key := suggestionKeys[req.Sort]
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	params := map[string]interface{}{
+		"limit":          req.Limit + 1,
+		"mechanicWeight": mechanicWeight,
+		"keywordWeight":  keywordWeight,
+		"typeWeight":     typeWeight,
+	}
+	for k, v := range q.params {
+		params[k] = v
+	}
+	recFilter := ""
+	if filter.Format != "" {
+		recFilter += " AND $format IN rec.formats"
+		params["format"] = string(filter.Format)
+	}
+	if filter.Identity != nil {
+		// nodes synced before identities were stored have none; skip them
+		recFilter += " AND rec.colorIdentity IS NOT NULL AND all(c IN rec.colorIdentity WHERE c IN $identity)"
+		params["identity"] = filter.Identity
+	}
+	where := ""
+	if cur != nil {
+		var curKey interface{} = cur.Key
+		var perr error
+		switch req.Sort {
+		case SortSimilarity, SortCMC:
+			curKey, perr = strconv.ParseFloat(cur.Key, 64)
+		}
+		if perr != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+		}
+		params["curKey"], params["curID"] = curKey, cur.ID
+		where = fmt.Sprintf("WHERE sortKey %[1]s $curKey OR (sortKey = $curKey AND rec.id %[1]s $curID)", cmp)
+	}
+
 	ctx := context.Background()
 	session := GraphDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
+	type suggestion struct {
+		id  string
+		key interface{}
+		why *SimilarityExplanation
+	}
+	var total int64
+
 	// 1. Execute the Graph Search
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		cypher := `
-			MATCH (source:Card {id: $id})
-			MATCH (source)-[:PRODUCES|HAS_KEYWORD|IS_TYPE]->(attr)
-			MATCH (rec:Card)-[:PRODUCES|HAS_KEYWORD|IS_TYPE]->(attr)
-			WHERE rec.id <> source.id
-			WITH rec, count(DISTINCT attr) AS sharedCount
-			ORDER BY sharedCount DESC
-			LIMIT 10
-			RETURN rec.id AS id
-		`
-		res, err := tx.Run(ctx, cypher, map[string]interface{}{"id": oracleID})
+		// the card count is the N in each attribute's IDF
+		res, err := tx.Run(ctx, "MATCH (c:Card) RETURN count(c) AS cards", nil)
 		if err != nil {
 			return nil, err
 		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		params["cards"], _ = record.Get("cards")
 
-		var ids []string
-		for res.Next(ctx) {
-			if id, ok := res.Record().Get("id"); ok {
-				ids = append(ids, id.(string))
+		match := q.match + recFilter + "\n"
+		// like pageCards, only the first page is counted
+		if cur == nil {
+			res, err = tx.Run(ctx, match+"RETURN count(DISTINCT rec) AS total", params)
+			if err != nil {
+				return nil, err
 			}
+			record, err = res.Single(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if n, ok := record.Get("total"); ok {
+				total, _ = n.(int64)
+			}
+		}
+
+		cypher := match + q.score + fmt.Sprintf(`
+			WITH rec, shared, score, %[4]s, %[1]s AS sortKey
+			%[2]s
+			RETURN rec.id AS id, sortKey, score, shared, %[4]s
+			ORDER BY sortKey %[3]s, rec.id %[3]s
+			LIMIT $limit
+		`, key, where, dir, q.columns)
+		res, err = tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, err
+		}
+
+		var found []suggestion
+		for res.Next(ctx) {
+			record := res.Record()
+			id, _ := record.Get("id")
+			sortKey, _ := record.Get("sortKey")
+			score, _ := record.Get("score")
+			shared, _ := record.Get("shared")
+			s, _ := score.(float64)
+			attrs, _ := shared.([]interface{})
+			found = append(found, suggestion{id: id.(string), key: sortKey, why: q.explain(s, attrs, record)})
 		}
-		return ids, nil
+		return found, res.Err()
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("graph search failed: %w", err)
 	}
 
-	suggestedIDs := result.([]string)
-	if len(suggestedIDs) == 0 {
-		return []models.Card{}, nil
+	found, _ := result.([]suggestion)
+	page := &CardPage{Data: []models.Card{}, Total: total, Explanations: map[string]*SimilarityExplanation{}}
+	if len(found) > req.Limit {
+		found = found[:req.Limit]
+		last := found[len(found)-1]
+		page.NextCursor = encodeCursor(pageCursor{Sort: req.Sort, Desc: desc, Key: fmt.Sprint(last.key), ID: last.id})
+	}
+	if len(found) == 0 {
+		return page, nil
+	}
+
+	suggestedIDs := make([]string, len(found))
+	explanations := make(map[string]*SimilarityExplanation, len(found))
+	for i, f := range found {
+		suggestedIDs[i] = f.id
+		explanations[f.id] = f.why
 	}
 
     // 1. Fetch the cards from Postgres
@@ -79,61 +269,160 @@ func GetCardSuggestions(oracleID string) ([]models.Card, error) {
     }
 
     // 3. Rebuild the slice in the EXACT order of suggestedIDs
-    orderedCards := make([]models.Card, 0, len(suggestedIDs))
     for _, id := range suggestedIDs {
         if card, exists := cardMap[id]; exists {
-            orderedCards = append(orderedCards, card)
+            page.Data = append(page.Data, card)
+            page.Explanations[card.ID] = explanations[id]
         }
     }
 
-    return orderedCards, nil
+    return page, nil
 }
 
-func syncToMemgraph(cards []*models.Card) error {
-    ctx := context.Background()
-    session := GraphDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-    defer session.Close(ctx)
+// graphCard is the "lean" view of a card that Memgraph stores
+type graphCard struct {
+    ID        string
+    Name      string
+    ManaCost  string
+    CMC       float64
+    TypeLine  string
+    Types     []string
+    Keywords  []string
+    Mechanics []string
+    Formats   []string // formats the card is playable in
+    Identity  []string // colour identity, for commander suggestions
+}
+
+// newGraphCard builds the graph view of a card. Types and mechanics are the
+// union over its faces, so both halves of a split, adventure or double-faced
+// card count; each face's text refers to itself by its own name.
+func newGraphCard(c *models.Card) graphCard {
+    types, mechs := []string{}, []string{}
+    for _, f := range c.Faces() {
+        types = append(types, processTypes(f.TypeLine)...)
+        mechs = append(mechs, mechanics.Default().Extract(f.Name, f.OracleText)...)
+    }
+    return graphCard{
+        ID:        *c.OracleID,
+        Name:      c.Name,
+        ManaCost:  derefString(c.ManaCost),
+        CMC:       derefFloat(c.CMC),
+        TypeLine:  c.TypeLine,
+        Types:     types,
+        Keywords:  []string(c.Keywords),
+        Mechanics: mechs,
+        Formats:   c.Legalities.PlayableFormats(),
+        Identity:  append([]string{}, c.ColorIdentity...),
+    }
+}
 
+func (g graphCard) data() map[string]interface{} {
+    return map[string]interface{}{
+        "id":          g.ID,
+        "name":        g.Name,
+        "manaCost":    g.ManaCost,
+        "cmc":         g.CMC,
+        "typeLine":    g.TypeLine,
+        "types":       g.Types,
+        "keywords":    g.Keywords,
+        "mechanics":   g.Mechanics,
+        "formats":     g.Formats,
+        "identity":    g.Identity,
+    }
+}
+
+// normalizeSet sorts and de-duplicates, matching how the graph stores
+// relationships (a node links to each Type/Keyword/Mechanic at most once)
+func normalizeSet(in []string) []string {
+    out := append([]string(nil), in...)
+    sort.Strings(out)
+    return slices.Compact(out)
+}
+
+// fingerprint hashes the parts of a card the graph actually keeps
+// (name, cmc, types, keywords, mechanics, formats, identity), ignoring
+// order and duplicates.
+func (g graphCard) fingerprint() string {
+    join := func(in []string) string { return strings.Join(normalizeSet(in), "\x1f") }
+    sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x1e%g\x1e%s\x1e%s\x1e%s\x1e%s\x1e%s",
+        g.Name, g.CMC, join(g.Types), join(g.Keywords), join(g.Mechanics), join(g.Formats), join(g.Identity))))
+    return hex.EncodeToString(sum[:])
+}
+
+// diff names the graph fields that differ between two views of a card
+func (g graphCard) diff(other graphCard) []string {
+    var fields []string
+    if g.Name != other.Name {
+        fields = append(fields, "name")
+    }
+    if g.CMC != other.CMC {
+        fields = append(fields, "cmc")
+    }
+    if !slices.Equal(normalizeSet(g.Types), normalizeSet(other.Types)) {
+        fields = append(fields, "types")
+    }
+    if !slices.Equal(normalizeSet(g.Keywords), normalizeSet(other.Keywords)) {
+        fields = append(fields, "keywords")
+    }
+    if !slices.Equal(normalizeSet(g.Mechanics), normalizeSet(other.Mechanics)) {
+        fields = append(fields, "mechanics")
+    }
+    if !slices.Equal(normalizeSet(g.Formats), normalizeSet(other.Formats)) {
+        fields = append(fields, "formats")
+    }
+    if !slices.Equal(normalizeSet(g.Identity), normalizeSet(other.Identity)) {
+        fields = append(fields, "identity")
+    }
+    return fields
+}
+
+func syncToMemgraph(cards []*models.Card) error {
     // Prepare a "lean" map for Memgraph ingestion
     var batchData []map[string]interface{}
     for _, c := range cards {
 		if c.OracleID == nil { continue }
-        batchData = append(batchData, map[string]interface{}{
-            "id":          c.OracleID,
-            "name":        c.Name,
-            "manaCost":    derefString(c.ManaCost),
-            "cmc":         derefFloat(c.CMC),
-            "typeLine":    c.TypeLine,
-            "types":       processTypes(c.TypeLine),
-            "keywords":    c.Keywords,
-            "mechanics":   extractMechanics(derefString(c.OracleText)),
-        })
-    }
-
-    // The "Power Query": Updates nodes and relationships in one go
+        batchData = append(batchData, newGraphCard(c).data())
+    }
+    return writeGraphBatch(batchData)
+}
+
+func writeGraphBatch(batchData []map[string]interface{}) error {
+    if len(batchData) == 0 {
+        return nil
+    }
+    ctx := context.Background()
+    session := GraphDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+    defer session.Close(ctx)
+
+    // The "Power Query": Updates nodes and relationships in one go.
+    // Old relationships are dropped first so a changed card loses mechanics
+    // it no longer has, and FOREACH keeps an empty list from ending the row.
     _, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
         query := `
         UNWIND $batch AS data
         MERGE (c:Card {id: data.id})
-        SET c.name = data.name, c.cmc = data.cmc
-        
-        // Connect Types
+        SET c.name = data.name, c.cmc = data.cmc, c.formats = data.formats,
+            c.colorIdentity = data.identity
+
         WITH c, data
-        UNWIND data.types AS tName
-        MERGE (t:Type {name: tName})
-        MERGE (c)-[:IS_TYPE]->(t)
-        
+        OPTIONAL MATCH (c)-[old:IS_TYPE|HAS_KEYWORD|PRODUCES]->()
+        DELETE old
+
+        WITH DISTINCT c, data
+        // Connect Types
+        FOREACH (tName IN data.types |
+            MERGE (t:Type {name: tName})
+            MERGE (c)-[:IS_TYPE]->(t))
+
         // Connect Keywords
-        WITH c, data
-        UNWIND data.keywords AS kName
-        MERGE (k:Keyword {name: kName})
-        MERGE (c)-[:HAS_KEYWORD]->(k)
-        
+        FOREACH (kName IN data.keywords |
+            MERGE (k:Keyword {name: kName})
+            MERGE (c)-[:HAS_KEYWORD]->(k))
+
         // Connect Mechanics
-        WITH c, data
-        UNWIND data.mechanics AS mName
-        MERGE (m:Mechanic {name: mName})
-        MERGE (c)-[:PRODUCES]->(m)
+        FOREACH (mName IN data.mechanics |
+            MERGE (m:Mechanic {name: mName})
+            MERGE (c)-[:PRODUCES]->(m))
         `
         return tx.Run(ctx, query, map[string]interface{}{"batch": batchData})
     })
@@ -141,6 +430,25 @@ func syncToMemgraph(cards []*models.Card) error {
     return err
 }
 
+// deleteFromMemgraph removes card nodes and their relationships
+func deleteFromMemgraph(oracleIDs []string) error {
+    if len(oracleIDs) == 0 {
+        return nil
+    }
+    ctx := context.Background()
+    session := GraphDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+    defer session.Close(ctx)
+
+    _, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+        return tx.Run(ctx, `
+        UNWIND $ids AS id
+        MATCH (c:Card {id: id})
+        DETACH DELETE c
+        `, map[string]interface{}{"ids": oracleIDs})
+    })
+    return err
+}
+
 func GetMemgraphCardCount() int64 {
 	ctx := context.Background()
 	session := GraphDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
@@ -159,32 +467,6 @@ func GetMemgraphCardCount() int64 {
 }
 
 
-// This is what needs to get smart.
-// Maybe we throw a llm at this too?
-func extractMechanics(oracleText string) []string {
-	mechanics := []string{}
-	text := strings.ToLower(oracleText)
-
-	// Simple pattern matching for core synergies
-	mapping := map[string][]string{
-		"Draw":      {"draw a card", "draws a card"},
-		"Ramp":      {"search your library for a land", "add {g}", "add {u}", "add {r}", "add {b}", "add {w}"},
-		"Token":     {"create", "token"},
-		"Lifegain":  {"gain", "life"},
-		"Graveyard": {"return", "graveyard", "exile from your graveyard"},
-	}
-
-	for mech, keywords := range mapping {
-		for _, kw := range keywords {
-			if strings.Contains(text, kw) {
-				mechanics = append(mechanics, mech)
-				break 
-			}
-		}
-	}
-	return mechanics
-}
-
 func executeSchema(ctx context.Context) {
 	session := GraphDriver.NewSession(ctx, neo4j.SessionConfig{})
 	defer session.Close(ctx)
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"go-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// dataMigrations rewrite existing rows and run once each, in order. Append
+// new ones; never rename or reorder applied ones.
+var dataMigrations = []struct {
+	name string
+	run  func(tx *gorm.DB) error
+}{
+	{"card_json_typed", migrateCardJSON},
+}
+
+// dataMigrationLock is the advisory lock key that keeps two starting servers
+// from running the same migration
+const dataMigrationLock = 7_301_017
+
+// runDataMigrations applies the data migrations not yet recorded in
+// data_migrations, each in its own transaction with its record.
+func runDataMigrations() error {
+	if err := DB.AutoMigrate(&models.DataMigration{}); err != nil {
+		return fmt.Errorf("data migration table: %w", err)
+	}
+	for _, m := range dataMigrations {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", dataMigrationLock).Error; err != nil {
+				return err
+			}
+			err := tx.First(&models.DataMigration{}, "name = ?", m.name).Error
+			if err == nil {
+				return nil
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err := m.run(tx); err != nil {
+				return err
+			}
+			fmt.Printf("Database: applied data migration %s\n", m.name)
+			return tx.Create(&models.DataMigration{Name: m.name}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("data migration %s failed: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// migrateCardJSON brings jsonb card columns written before they were typed
+// in line with what the models now write: JSON nulls become SQL NULLs, and
+// prices, which Scryfall sends as strings, become numbers.
+func migrateCardJSON(tx *gorm.DB) error {
+	steps := []struct{ name, sql string }{
+		{"null card_faces", `UPDATE cards SET card_faces = NULL WHERE jsonb_typeof(card_faces) = 'null'`},
+		{"null image_uris", `UPDATE cards SET image_uris = NULL WHERE jsonb_typeof(image_uris) = 'null'`},
+		{"null legalities", `UPDATE cards SET legalities = NULL WHERE jsonb_typeof(legalities) = 'null'`},
+		{"null prices", `UPDATE cards SET prices = NULL WHERE jsonb_typeof(prices) = 'null'`},
+		{"string prices", `UPDATE cards SET prices = (
+			SELECT jsonb_object_agg(key, CASE
+				WHEN jsonb_typeof(value) <> 'string' THEN value
+				WHEN value #>> '{}' ~ '^[0-9]+(\.[0-9]+){0,1}$' THEN to_jsonb(round((value #>> '{}')::numeric, 2))
+				ELSE 'null'::jsonb
+			END)
+			FROM jsonb_each(prices))
+		WHERE jsonb_typeof(prices) = 'object'
+			AND EXISTS (SELECT 1 FROM jsonb_each(prices) WHERE jsonb_typeof(value) = 'string')`},
+	}
+	for _, step := range steps {
+		result := tx.Exec(step.sql)
+		if result.Error != nil {
+			return fmt.Errorf("%s: %w", step.name, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			fmt.Printf("Database: migrated %s in %d rows\n", step.name, result.RowsAffected)
+		}
+	}
+	return nil
+}
+
+// migrateLegalityIndex indexes legalities for the containment checks that
+// format filters use.
+func migrateLegalityIndex() error {
+	err := DB.Exec(`CREATE INDEX IF NOT EXISTS idx_cards_legalities ON cards USING GIN (legalities jsonb_path_ops)`).Error
+	if err != nil {
+		return fmt.Errorf("legality index migration failed: %w", err)
+	}
+	return nil
+}
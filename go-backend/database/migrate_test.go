@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+
+	"go-backend/models"
+)
+
+func TestDataMigrationsRunOnce(t *testing.T) {
+	openTestDB(t)
+	err := DB.Exec(`INSERT INTO cards (id, name, type_line, set_code, rarity, prices, image_uris)
+		VALUES ('old', 'Opt', 'Instant', 'xln', 'common', '{"usd": "0.25", "eur": null}', 'null')`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runDataMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	var card models.Card
+	if err := DB.First(&card, "id = ?", "old").Error; err != nil {
+		t.Fatal(err)
+	}
+	if card.Prices == nil || card.Prices.USD == nil || card.Prices.USD.String() != "0.25" {
+		t.Errorf("prices = %+v, want usd 0.25", card.Prices)
+	}
+	if card.ImageURIs != nil {
+		t.Errorf("image_uris = %+v, want NULL", card.ImageURIs)
+	}
+
+	// A row written in the old shape after the migration ran stays as it is
+	err = DB.Exec(`UPDATE cards SET image_uris = 'null' WHERE id = 'old'`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runDataMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	var untouched int64
+	DB.Model(&models.Card{}).Where("jsonb_typeof(image_uris) = 'null'").Count(&untouched)
+	if untouched != 1 {
+		t.Errorf("second start rewrote rows again")
+	}
+	var applied int64
+	DB.Model(&models.DataMigration{}).Count(&applied)
+	if applied != int64(len(dataMigrations)) {
+		t.Errorf("%d migrations recorded, want %d", applied, len(dataMigrations))
+	}
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"fmt"
+	"go-backend/autocomplete"
+	"go-backend/models"
+	"log"
+	"sync/atomic"
+)
+
+// nameIndex serves /api/cards/autocomplete; nil until the first build.
+var nameIndex atomic.Pointer[autocomplete.Trie]
+
+// RefreshNameIndex rebuilds the autocomplete trie from the distinct names a
+// lookup can resolve (see namedCardFilter) and swaps it in, so type-ahead
+// never offers tokens, emblems or art cards.
+func RefreshNameIndex() error {
+	var names []string
+	if err := DB.Model(&models.Card{}).Where(namedCardFilter).Distinct().Pluck("name", &names).Error; err != nil {
+		return fmt.Errorf("failed to load card names: %w", err)
+	}
+	trie := autocomplete.Build(names)
+	nameIndex.Store(trie)
+	log.Printf("Autocomplete: indexed %d card names", trie.Len())
+	return nil
+}
+
+// CompleteCardName returns up to limit English card names starting with
+// prefix, ignoring case and accents.
+func CompleteCardName(prefix string, limit int) []string {
+	trie := nameIndex.Load()
+	if trie == nil {
+		return []string{}
+	}
+	return trie.Complete(prefix, limit)
+}
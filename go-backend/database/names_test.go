@@ -0,0 +1,20 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestNameIndexSkipsArtAndTokenCards checks type-ahead offers the real
+// card's name only, not the art card sharing it.
+func TestNameIndexSkipsArtAndTokenCards(t *testing.T) {
+	openTestDB(t)
+	createTarmogoyfs(t)
+
+	if err := RefreshNameIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if got := CompleteCardName("tarmo", 10); !reflect.DeepEqual(got, []string{"Tarmogoyf"}) {
+		t.Errorf("got %q, want [Tarmogoyf]", got)
+	}
+}
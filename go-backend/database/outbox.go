@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"go-backend/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	outboxBatchSize    = 500
+	outboxPollInterval = 2 * time.Second
+	outboxMaxAttempts  = 8
+	outboxBaseBackoff  = 2 * time.Second
+	outboxMaxBackoff   = 10 * time.Minute
+	// outboxClaimLease is how long a claimed event is hidden from other
+	// relays while it is applied
+	outboxClaimLease = 5 * time.Minute
+)
+
+// enqueueGraphChanges records oracle IDs the relay must push to Memgraph.
+// Call it with the transaction that changes the cards.
+func enqueueGraphChanges(tx *gorm.DB, op string, oracleIDs []string) error {
+	if len(oracleIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	events := make([]models.OutboxEvent, 0, len(oracleIDs))
+	seen := make(map[string]bool, len(oracleIDs))
+	for _, id := range oracleIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		events = append(events, models.OutboxEvent{
+			OracleID:      id,
+			Op:            op,
+			Status:        models.OutboxPending,
+			NextAttemptAt: now,
+		})
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	return tx.Create(&events).Error
+}
+
+// enqueueChangedCards queues the oracle IDs of cards in ids whose row was
+// written with updated_at = stamp, i.e. new or actually changed by an upsert.
+func enqueueChangedCards(tx *gorm.DB, ids []string, stamp time.Time) error {
+	var changed []string
+	err := tx.Model(&models.Card{}).
+		Where("id IN ? AND updated_at = ? AND oracle_id IS NOT NULL", ids, stamp).
+		Distinct().Pluck("oracle_id", &changed).Error
+	if err != nil {
+		return err
+	}
+	return enqueueGraphChanges(tx, models.OutboxUpsert, changed)
+}
+
+// StartOutboxRelay drains the outbox into Memgraph until ctx is cancelled.
+func StartOutboxRelay(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(outboxPollInterval)
+		defer ticker.Stop()
+		for {
+			// Keep draining while there is a backlog, then wait for the next tick
+			for {
+				n, err := relayOutboxBatch(ctx)
+				if err != nil {
+					log.Printf("Outbox relay: %v", err)
+				}
+				if n < outboxBatchSize || err != nil {
+					break
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// relayOutboxBatch claims a batch of due events, applies them to Memgraph and
+// deletes them. Events for an oracle ID that fails to apply are retried with
+// exponential backoff and parked as dead after outboxMaxAttempts; the rest
+// of the batch goes through. Returns how many events it claimed.
+func relayOutboxBatch(ctx context.Context) (int, error) {
+	events, err := claimOutboxEvents(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	oracleIDs := make([]string, 0, len(events))
+	seen := make(map[string]bool, len(events))
+	for _, e := range events {
+		if !seen[e.OracleID] {
+			seen[e.OracleID] = true
+			oracleIDs = append(oracleIDs, e.OracleID)
+		}
+	}
+	failures, err := applyGraphChanges(oracleIDs)
+	if err != nil {
+		// Nothing was applied; the claim runs out and the batch is retried
+		return len(events), err
+	}
+
+	var done []uint
+	for _, e := range events {
+		applyErr, failed := failures[e.OracleID]
+		if !failed {
+			done = append(done, e.ID)
+			continue
+		}
+		e.Attempts++
+		e.LastError = applyErr.Error()
+		if e.Attempts >= outboxMaxAttempts {
+			e.Status = models.OutboxDead
+			log.Printf("Outbox relay: giving up on %s %s after %d attempts: %v", e.Op, e.OracleID, e.Attempts, applyErr)
+		} else {
+			e.NextAttemptAt = time.Now().Add(outboxBackoff(e.Attempts))
+		}
+		if err := DB.WithContext(ctx).Save(&e).Error; err != nil {
+			return len(events), fmt.Errorf("failed to reschedule outbox event %d: %w", e.ID, err)
+		}
+	}
+	if len(done) > 0 {
+		if err := DB.WithContext(ctx).Where("id IN ?", done).Delete(&models.OutboxEvent{}).Error; err != nil {
+			return len(events), fmt.Errorf("failed to delete applied outbox events: %w", err)
+		}
+	}
+	if len(failures) > 0 {
+		return len(events), fmt.Errorf("%d of %d oracle IDs failed to apply, will retry", len(failures), len(oracleIDs))
+	}
+	return len(events), nil
+}
+
+// claimOutboxEvents picks due events and pushes their next attempt out by
+// outboxClaimLease before committing, so Memgraph is written outside the
+// transaction and no other relay takes them meanwhile. If the relay dies
+// mid-batch the claim lapses and the events are picked up again.
+func claimOutboxEvents(ctx context.Context) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// SKIP LOCKED lets several relays claim at once without overlapping
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+			Order("id").Limit(outboxBatchSize).Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		ids := make([]uint, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(outboxClaimLease)).Error
+	})
+	return events, err
+}
+
+// applyGraphChanges brings the graph in line with Postgres for each oracle
+// ID: the newest live printing is synced, and IDs with no live printing left
+// are removed from the graph. The IDs go to Memgraph together; if that
+// fails they are retried one by one, and the ones that still fail are
+// returned with their errors. An error means Postgres couldn't be read and
+// nothing was applied.
+func applyGraphChanges(oracleIDs []string) (map[string]error, error) {
+	var cards []*models.Card
+	err := DB.Raw(`
+		SELECT DISTINCT ON (oracle_id) * FROM cards
+		WHERE oracle_id IN ? AND deleted_at IS NULL
+		ORDER BY oracle_id, released_at DESC`, oracleIDs).Scan(&cards).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cards: %w", err)
+	}
+
+	byID := make(map[string]*models.Card, len(cards))
+	for _, c := range cards {
+		byID[*c.OracleID] = c
+	}
+	var gone []string
+	for _, id := range oracleIDs {
+		if byID[id] == nil {
+			gone = append(gone, id)
+		}
+	}
+
+	failures := make(map[string]error)
+	if applyGraphChange(cards, gone) == nil {
+		return failures, nil
+	}
+	for _, id := range oracleIDs {
+		var err error
+		if c := byID[id]; c != nil {
+			err = applyGraphChange([]*models.Card{c}, nil)
+		} else {
+			err = applyGraphChange(nil, []string{id})
+		}
+		if err != nil {
+			failures[id] = err
+		}
+	}
+	return failures, nil
+}
+
+// applyGraphChange syncs cards and removes the gone oracle IDs from the graph.
+func applyGraphChange(cards []*models.Card, gone []string) error {
+	if _, err := syncChangedCards(cards, false); err != nil {
+		return err
+	}
+	if err := deleteFromMemgraph(gone); err != nil {
+		return fmt.Errorf("failed to remove deleted cards from Memgraph: %w", err)
+	}
+	if len(gone) > 0 {
+		if err := DB.Where("oracle_id IN ?", gone).Delete(&models.GraphSyncState{}).Error; err != nil {
+			return fmt.Errorf("failed to clear graph sync state: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeadOutboxEvents lists events the relay gave up on, oldest first. Their
+// cards may be stale in the graph until the next full or incremental sync.
+func DeadOutboxEvents() ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := DB.Where("status = ?", models.OutboxDead).Order("id").Find(&events).Error
+	return events, err
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	d := outboxBaseBackoff << (attempts - 1)
+	if d <= 0 || d > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return d
+}
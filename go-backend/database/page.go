@@ -0,0 +1,204 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidPage wraps problems with a client's limit, sort or cursor.
+var ErrInvalidPage = errors.New("invalid page request")
+
+const maxPageLimit = 100
+
+// SortField names an order a card list can be returned in.
+type SortField string
+
+const (
+	SortName       SortField = "name"
+	SortCMC        SortField = "cmc"
+	SortReleased   SortField = "released_at"
+	SortPrice      SortField = "price"
+	SortSimilarity SortField = "similarity"
+	SortRank       SortField = "rank"
+)
+
+// PageRequest asks for Limit cards after Cursor. An empty Sort uses the
+// endpoint's default order; Dir is "asc", "desc" or empty for the sort's
+// natural direction.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+	Sort   SortField
+	Dir    string
+}
+
+// CardPage is the envelope every card list endpoint returns.
+type CardPage struct {
+	Data       []models.Card `json:"data"`
+	NextCursor string        `json:"next_cursor"` // empty on the last page
+	// Total is only counted on the first page
+	Total int64 `json:"total"`
+	// Facets is only filled by SearchCards, on the first page
+	Facets *SearchFacets `json:"facets,omitempty"`
+	// Explanations is only filled by GetCardSuggestions, keyed by card ID
+	Explanations map[string]*SimilarityExplanation `json:"explanations,omitempty"`
+}
+
+type sortSpec struct {
+	// key is the sort expression over the listed rows; %s is the stand-in
+	// for a missing price so NULLs sort last in either direction
+	key  string
+	cast string
+	desc bool
+}
+
+// similarity() and ts_rank() return real, so they are widened: the key's
+// text and the cursor comparison must share one precision or ties at a
+// page boundary are skipped or repeated.
+var cardSorts = map[SortField]sortSpec{
+	SortName:       {key: "name", cast: "text"},
+	SortCMC:        {key: "COALESCE(cmc, 0)::float8", cast: "float8"},
+	SortReleased:   {key: "COALESCE(released_at, '')", cast: "text", desc: true},
+	SortPrice:      {key: "COALESCE(NULLIF(prices->>'usd', '')::float8, '%s')", cast: "float8"},
+	SortSimilarity: {key: "similarity::float8", cast: "float8", desc: true},
+	SortRank:       {key: "rank::float8", cast: "float8", desc: true},
+}
+
+// pageCursor is what next_cursor encodes: the sort key and ID of the last
+// card served, plus the order it was served in so a cursor can't be
+// replayed against a different sort.
+type pageCursor struct {
+	Sort SortField `json:"s"`
+	Desc bool      `json:"d"`
+	Key  string    `json:"k"`
+	ID   string    `json:"i"`
+}
+
+func encodeCursor(c pageCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+	}
+	var c pageCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+	}
+	return &c, nil
+}
+
+// resolve validates a request against the sorts an endpoint supports and
+// fills in defaults. It returns the decoded cursor, if any.
+func (req *PageRequest) resolve(defaultSort SortField, allowed ...SortField) (bool, *pageCursor, error) {
+	if req.Limit <= 0 || req.Limit > maxPageLimit {
+		return false, nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidPage, maxPageLimit)
+	}
+	if req.Sort == "" {
+		req.Sort = defaultSort
+	}
+	ok := false
+	for _, s := range allowed {
+		ok = ok || s == req.Sort
+	}
+	spec, known := cardSorts[req.Sort]
+	if !ok || !known {
+		return false, nil, fmt.Errorf("%w: cannot sort by %q here", ErrInvalidPage, req.Sort)
+	}
+
+	desc := spec.desc
+	switch req.Dir {
+	case "":
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	default:
+		return false, nil, fmt.Errorf("%w: dir must be asc or desc", ErrInvalidPage)
+	}
+
+	if req.Cursor == "" {
+		return desc, nil, nil
+	}
+	cur, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return false, nil, err
+	}
+	if cur.Sort != req.Sort || cur.Desc != desc {
+		return false, nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidPage)
+	}
+	return desc, cur, nil
+}
+
+// pagedCard is a card plus the text form of its sort key, for the cursor
+type pagedCard struct {
+	models.Card `gorm:"embedded"`
+	PageKey     string `gorm:"column:page_key"`
+}
+
+// pageCards serves one keyset page of base, a SELECT returning card rows
+// (and a similarity or rank column if that sort is allowed). Rows are
+// ordered by the sort key with the card ID as tie-breaker, so pages never
+// skip or repeat a card while the underlying data is unchanged.
+func pageCards(db *gorm.DB, base string, args []interface{}, req PageRequest, defaultSort SortField, allowed ...SortField) (*CardPage, error) {
+	desc, cur, err := req.resolve(defaultSort, allowed...)
+	if err != nil {
+		return nil, err
+	}
+	spec := cardSorts[req.Sort]
+	key := spec.key
+	if req.Sort == SortPrice {
+		if desc {
+			key = fmt.Sprintf(key, "-Infinity")
+		} else {
+			key = fmt.Sprintf(key, "Infinity")
+		}
+	}
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	queryArgs := append([]interface{}{}, args...)
+	where := ""
+	if cur != nil {
+		where = fmt.Sprintf("WHERE (%s, id) %s (CAST(? AS %s), ?)", key, cmp, spec.cast)
+		queryArgs = append(queryArgs, cur.Key, cur.ID)
+	}
+	queryArgs = append(queryArgs, req.Limit+1)
+
+	var rows []pagedCard
+	err = db.Raw(fmt.Sprintf(`
+		SELECT page.*, (%[1]s)::text AS page_key
+		FROM (%[2]s) AS page
+		%[3]s
+		ORDER BY %[1]s %[4]s, id %[4]s
+		LIMIT ?`, key, base, where, dir), queryArgs...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CardPage{Data: make([]models.Card, 0, len(rows))}
+	if cur == nil {
+		if err := db.Raw("SELECT count(*) FROM ("+base+") AS total", args...).Scan(&page.Total).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(rows) > req.Limit {
+		rows = rows[:req.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(pageCursor{Sort: req.Sort, Desc: desc, Key: last.PageKey, ID: last.ID})
+	}
+	for _, r := range rows {
+		page.Data = append(page.Data, r.Card)
+	}
+	return page, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"go-backend/models"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	c := pageCursor{Sort: SortSimilarity, Desc: true, Key: "0.6153846153846154", ID: "abc"}
+	got, err := decodeCursor(encodeCursor(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != c {
+		t.Errorf("decoded %+v, want %+v", *got, c)
+	}
+	if _, err := decodeCursor("not a cursor!"); err == nil {
+		t.Error("decoding garbage succeeded")
+	}
+}
+
+func TestPageRequestResolve(t *testing.T) {
+	cursor := encodeCursor(pageCursor{Sort: SortName, Key: "Opt", ID: "x"})
+	for name, tc := range map[string]struct {
+		req     PageRequest
+		desc    bool
+		wantErr bool
+	}{
+		"default sort":       {req: PageRequest{Limit: 10}, desc: true},
+		"explicit direction": {req: PageRequest{Limit: 10, Sort: SortName, Dir: "desc"}, desc: true},
+		"zero limit":         {req: PageRequest{Limit: 0}, wantErr: true},
+		"limit too high":     {req: PageRequest{Limit: maxPageLimit + 1}, wantErr: true},
+		"sort not allowed":   {req: PageRequest{Limit: 10, Sort: SortRank}, wantErr: true},
+		"bad direction":      {req: PageRequest{Limit: 10, Dir: "up"}, wantErr: true},
+		"matching cursor":    {req: PageRequest{Limit: 10, Sort: SortName, Cursor: cursor}},
+		"cursor for a different order": {
+			req: PageRequest{Limit: 10, Sort: SortName, Dir: "desc", Cursor: cursor}, wantErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := tc.req
+			desc, _, err := req.resolve(SortSimilarity, SortSimilarity, SortName)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tc.wantErr)
+			}
+			if err == nil && desc != tc.desc {
+				t.Errorf("desc = %v, want %v", desc, tc.desc)
+			}
+		})
+	}
+}
+
+// TestFuzzyPagesThroughTies walks the fuzzy search one card at a time over
+// names with equal similarity, which only the ID tie-breaker can order.
+func TestFuzzyPagesThroughTies(t *testing.T) {
+	openTestDB(t)
+	want := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		name := fmt.Sprintf("Goblin Guide %c", 'A'+i)
+		card := models.Card{
+			ID: fmt.Sprintf("00000000-0000-0000-0000-%012d", i), Name: name,
+			TypeLine: "Creature — Goblin", SetCode: "zen", Rarity: "rare", Lang: "en",
+		}
+		if err := DB.Create(&card).Error; err != nil {
+			t.Fatal(err)
+		}
+		want[name] = true
+	}
+
+	seen := map[string]bool{}
+	req := PageRequest{Limit: 1}
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatalf("still paging after %d pages", pages)
+		}
+		page, err := SearchCardByNameFuzzy("Goblin Guide", CardFilter{}, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pages == 0 && page.Total != int64(len(want)) {
+			t.Errorf("total = %d, want %d", page.Total, len(want))
+		}
+		for _, c := range page.Data {
+			if seen[c.Name] {
+				t.Errorf("%s served twice", c.Name)
+			}
+			seen[c.Name] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	if len(seen) != len(want) {
+		t.Errorf("served %d cards, want %d", len(seen), len(want))
+	}
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"go-backend/models"
+	"sort"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ParityReport compares what Postgres says the graph should contain with
+// what Memgraph actually holds, card by card.
+type ParityReport struct {
+	CheckedAt     time.Time     `json:"checked_at"`
+	PostgresCards int           `json:"postgres_cards"`
+	MemgraphCards int           `json:"memgraph_cards"`
+	InSync        bool          `json:"in_sync"`
+	Missing       []ParityEntry `json:"missing"` // in Postgres, not in the graph
+	Extra         []ParityEntry `json:"extra"`   // in the graph, not in Postgres
+	Drifted       []ParityEntry `json:"drifted"` // in both, with different data
+	// DeadEvents are outbox events the relay gave up on
+	DeadEvents []models.OutboxEvent `json:"dead_events"`
+}
+
+type ParityEntry struct {
+	OracleID string   `json:"oracle_id"`
+	Name     string   `json:"name"`
+	Fields   []string `json:"fields,omitempty"`
+}
+
+// CheckParity fingerprints every functional card on both sides. The
+// Postgres side is recomputed with the current type/mechanics extraction,
+// so errata and extractor changes both show up as drift.
+func CheckParity() (*ParityReport, error) {
+	expected := make(map[string]graphCard)
+	after := ""
+	for {
+		cards, err := fetchOracleCards(after, graphSyncBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch distinct cards: %w", err)
+		}
+		if len(cards) == 0 {
+			break
+		}
+		for _, c := range cards {
+			expected[*c.OracleID] = newGraphCard(c)
+		}
+		after = *cards[len(cards)-1].OracleID
+	}
+
+	actual, err := fetchGraphCards()
+	if err != nil {
+		return nil, err
+	}
+	dead, err := DeadOutboxEvents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead outbox events: %w", err)
+	}
+
+	report := &ParityReport{
+		CheckedAt:     time.Now(),
+		PostgresCards: len(expected),
+		MemgraphCards: len(actual),
+		Missing:       []ParityEntry{},
+		Extra:         []ParityEntry{},
+		Drifted:       []ParityEntry{},
+		DeadEvents:    dead,
+	}
+	for id, want := range expected {
+		got, ok := actual[id]
+		if !ok {
+			report.Missing = append(report.Missing, ParityEntry{OracleID: id, Name: want.Name})
+			continue
+		}
+		if want.fingerprint() != got.fingerprint() {
+			report.Drifted = append(report.Drifted, ParityEntry{OracleID: id, Name: want.Name, Fields: want.diff(got)})
+		}
+	}
+	for id, got := range actual {
+		if _, ok := expected[id]; !ok {
+			report.Extra = append(report.Extra, ParityEntry{OracleID: id, Name: got.Name})
+		}
+	}
+
+	for _, entries := range [][]ParityEntry{report.Missing, report.Extra, report.Drifted} {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].OracleID < entries[j].OracleID })
+	}
+	report.InSync = len(report.Missing) == 0 && len(report.Extra) == 0 && len(report.Drifted) == 0
+	return report, nil
+}
+
+// fetchGraphCards reads every card node back with its relationships
+func fetchGraphCards() (map[string]graphCard, error) {
+	ctx := context.Background()
+	session := GraphDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	out := make(map[string]graphCard)
+	after := ""
+	for {
+		result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			cypher := `
+				MATCH (c:Card)
+				WHERE c.id > $after
+				WITH c ORDER BY c.id LIMIT $limit
+				OPTIONAL MATCH (c)-[:IS_TYPE]->(t:Type)
+				WITH c, collect(DISTINCT t.name) AS types
+				OPTIONAL MATCH (c)-[:HAS_KEYWORD]->(k:Keyword)
+				WITH c, types, collect(DISTINCT k.name) AS keywords
+				OPTIONAL MATCH (c)-[:PRODUCES]->(m:Mechanic)
+				WITH c, types, keywords, collect(DISTINCT m.name) AS mechanics
+				RETURN c.id AS id, c.name AS name, c.cmc AS cmc, types, keywords, mechanics,
+					coalesce(c.formats, []) AS formats, coalesce(c.colorIdentity, []) AS identity
+				ORDER BY id
+			`
+			res, err := tx.Run(ctx, cypher, map[string]interface{}{"after": after, "limit": graphSyncBatchSize})
+			if err != nil {
+				return nil, err
+			}
+
+			var cards []graphCard
+			for res.Next(ctx) {
+				rec := res.Record()
+				card := graphCard{}
+				if v, ok := rec.Get("id"); ok {
+					card.ID, _ = v.(string)
+				}
+				if v, ok := rec.Get("name"); ok {
+					card.Name, _ = v.(string)
+				}
+				if v, ok := rec.Get("cmc"); ok {
+					switch n := v.(type) {
+					case float64:
+						card.CMC = n
+					case int64:
+						card.CMC = float64(n)
+					}
+				}
+				card.Types = recordStrings(rec, "types")
+				card.Keywords = recordStrings(rec, "keywords")
+				card.Mechanics = recordStrings(rec, "mechanics")
+				card.Formats = recordStrings(rec, "formats")
+				card.Identity = recordStrings(rec, "identity")
+				cards = append(cards, card)
+			}
+			return cards, res.Err()
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read graph cards: %w", err)
+		}
+
+		cards := result.([]graphCard)
+		if len(cards) == 0 {
+			break
+		}
+		for _, c := range cards {
+			out[c.ID] = c
+		}
+		after = cards[len(cards)-1].ID
+	}
+	return out, nil
+}
+
+func recordStrings(rec *neo4j.Record, key string) []string {
+	v, ok := rec.Get(key)
+	if !ok {
+		return nil
+	}
+	list, _ := v.([]interface{})
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,529 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-backend/models"
+	"io"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PrimeOptions controls how PrimeDatabase treats an existing checkpoint and
+// how many goroutines each stage of the ingestion pipeline gets.
+type PrimeOptions struct {
+	// Source identifies the bulk file (usually its absolute path) and keys the checkpoint.
+	Source string
+	// Size is the byte size of the file, or -1 when it cannot be determined.
+	Size int64
+	// Restart ignores any saved checkpoint and primes from the first card.
+	Restart bool
+	// BulkType says which Scryfall bulk file this is. Defaults to all_cards.
+	BulkType BulkType
+	// MapWorkers is the number of goroutines mapping Scryfall JSON to cards.
+	// Defaults to the number of CPUs.
+	MapWorkers int
+	// InsertWorkers is the number of goroutines upserting batches, each with
+	// its own GORM session. Defaults to 4.
+	InsertWorkers int
+}
+
+const primeBatchSize = 1000
+
+// primeBatch is one unit of work moving through the pipeline. seq orders
+// batches so the checkpoint only advances over a contiguous prefix.
+type primeBatch struct {
+	seq       int64
+	raw       []map[string]interface{}
+	cards     []*models.Card
+	rulings   []*models.Ruling
+	count     int // records decoded, including skipped ones
+	endOffset int64
+	lastID    string
+}
+
+// primeStage accumulates how much work a pipeline stage did and how long
+// its goroutines spent doing it, excluding time blocked on channels.
+type primeStage struct {
+	name    string
+	workers int
+	records atomic.Int64
+	busy    atomic.Int64 // nanoseconds
+}
+
+func (s *primeStage) record(n int, d time.Duration) {
+	s.records.Add(int64(n))
+	s.busy.Add(int64(d))
+}
+
+// report shows the stage's capacity (records/sec if it never waited) and
+// utilisation. The stage closest to 100% busy is the bottleneck.
+func (s *primeStage) report(elapsed time.Duration) string {
+	busy := time.Duration(s.busy.Load())
+	var capacity, utilisation float64
+	if busy > 0 {
+		capacity = float64(s.records.Load()) / busy.Seconds() * float64(s.workers)
+	}
+	if elapsed > 0 {
+		utilisation = 100 * busy.Seconds() / (elapsed.Seconds() * float64(s.workers))
+	}
+	return fmt.Sprintf("%s x%d: %.0f records/sec, %.0f%% busy", s.name, s.workers, capacity, utilisation)
+}
+
+// PrimeDatabase streams a Scryfall bulk file and batch inserts its records.
+// The file may be gzip-compressed and either a JSON array or NDJSON.
+//
+// Work is pipelined: one goroutine decodes, MapWorkers goroutines map to
+// models.Card and InsertWorkers goroutines upsert, connected by bounded
+// channels so a slow stage applies backpressure to the ones before it.
+// Batches can finish out of order, so the checkpoint is only moved past a
+// batch once every batch before it is in Postgres as well. Rerunning with
+// the same source resumes after the last checkpoint; batches inserted past
+// it are simply upserted again.
+func PrimeDatabase(file io.Reader, opts PrimeOptions) error {
+	if opts.MapWorkers <= 0 {
+		opts.MapWorkers = runtime.NumCPU()
+	}
+	if opts.InsertWorkers <= 0 {
+		opts.InsertWorkers = 4
+	}
+	if opts.BulkType == "" {
+		opts.BulkType = BulkAllCards
+	}
+	spec, ok := bulkSpecs[opts.BulkType]
+	if !ok {
+		return fmt.Errorf("unknown bulk type %q", opts.BulkType)
+	}
+
+	cp, err := loadPrimeCheckpoint(opts)
+	if err != nil {
+		return err
+	}
+
+	input, err := openBulkReader(file)
+	if err != nil {
+		return err
+	}
+	if closer, ok := input.(io.Closer); ok && input != file {
+		defer closer.Close()
+	}
+
+	decoder, baseOffset, err := openPrimeStream(input, cp, spec)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	decodeStage := &primeStage{name: "decode", workers: 1}
+	mapStage := &primeStage{name: "map", workers: opts.MapWorkers}
+	insertStage := &primeStage{name: "insert", workers: opts.InsertWorkers}
+
+	rawCh := make(chan *primeBatch, opts.MapWorkers*2)
+	cardCh := make(chan *primeBatch, opts.InsertWorkers*2)
+	doneCh := make(chan *primeBatch, opts.InsertWorkers*2)
+
+	startCount := cp.CardCount
+	startTime := time.Now()
+	if cp.CardCount > 0 {
+		log.Printf("Resuming database priming after %d cards (offset %d)...", cp.CardCount, cp.ByteOffset)
+	} else {
+		log.Println("Starting database priming...")
+	}
+	log.Printf("Prime pipeline (%s): 1 decoder, %d mappers, %d inserters", opts.BulkType, opts.MapWorkers, opts.InsertWorkers)
+
+	var skipped atomic.Int64
+
+	// 1. Decoder: the JSON stream can only be read sequentially
+	go func() {
+		defer close(rawCh)
+		var seq int64
+		batch := &primeBatch{seq: seq}
+		send := func() bool {
+			batch.endOffset = baseOffset + decoder.InputOffset()
+			batch.lastID, _ = batch.raw[len(batch.raw)-1][spec.idField].(string)
+			batch.count = len(batch.raw)
+			select {
+			case rawCh <- batch:
+			case <-ctx.Done():
+				return false
+			}
+			seq++
+			batch = &primeBatch{seq: seq}
+			return true
+		}
+
+		for {
+			start := time.Now()
+			if !decoder.More() {
+				break
+			}
+			var rawCard map[string]interface{}
+			if err := decoder.Decode(&rawCard); err != nil {
+				fail(fmt.Errorf("failed to decode record %d (offset %d): %w",
+					startCount+seq*primeBatchSize+int64(len(batch.raw))+1, baseOffset+decoder.InputOffset(), err))
+				return
+			}
+			batch.raw = append(batch.raw, rawCard)
+			decodeStage.record(1, time.Since(start))
+
+			if len(batch.raw) >= primeBatchSize && !send() {
+				return
+			}
+		}
+		if len(batch.raw) > 0 {
+			send()
+		}
+	}()
+
+	// 2. Mappers
+	var mapWG sync.WaitGroup
+	for i := 0; i < opts.MapWorkers; i++ {
+		mapWG.Add(1)
+		go func() {
+			defer mapWG.Done()
+			for batch := range rawCh {
+				start := time.Now()
+				for i, raw := range batch.raw {
+					valid, err := spec.validate(raw)
+					if err != nil {
+						fail(fmt.Errorf("record %d: %w", startCount+batch.seq*primeBatchSize+int64(i)+1, err))
+						return
+					}
+					if !valid {
+						skipped.Add(1)
+						continue
+					}
+					if spec.object == "ruling" {
+						batch.rulings = append(batch.rulings, models.MapScryfallToRuling(raw))
+					} else {
+						batch.cards = append(batch.cards, models.MapScryfallToCard(raw))
+					}
+				}
+				batch.raw = nil
+				mapStage.record(batch.count, time.Since(start))
+
+				select {
+				case cardCh <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		mapWG.Wait()
+		close(cardCh)
+	}()
+
+	// 3. Inserters, each with its own DB session
+	var insertWG sync.WaitGroup
+	for i := 0; i < opts.InsertWorkers; i++ {
+		insertWG.Add(1)
+		go func() {
+			defer insertWG.Done()
+			db := DB.Session(&gorm.Session{})
+			for batch := range cardCh {
+				if ctx.Err() != nil {
+					return
+				}
+				start := time.Now()
+				if len(batch.cards) > 0 {
+					if err := batchInsertCards(db, batch.cards); err != nil {
+						fail(fmt.Errorf("failed to insert batch %d: %w", batch.seq, err))
+						return
+					}
+				}
+				if len(batch.rulings) > 0 {
+					if err := batchInsertRulings(db, batch.rulings); err != nil {
+						fail(fmt.Errorf("failed to insert batch %d: %w", batch.seq, err))
+						return
+					}
+				}
+				insertStage.record(batch.count, time.Since(start))
+
+				batch.cards = nil
+				batch.rulings = nil
+				select {
+				case doneCh <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		insertWG.Wait()
+		close(doneCh)
+	}()
+
+	// 4. Advance the checkpoint over contiguous finished batches
+	pending := make(map[int64]*primeBatch)
+	var next int64
+	var batchCount int
+	for batch := range doneCh {
+		pending[batch.seq] = batch
+		advanced := false
+		for {
+			b, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			cp.ByteOffset = b.endOffset
+			cp.CardCount += int64(b.count)
+			cp.LastCardID = b.lastID
+			advanced = true
+			batchCount++
+
+			// Progress update every 10 batches (10,000 cards)
+			if batchCount%10 == 0 {
+				elapsed := time.Since(startTime)
+				rate := float64(cp.CardCount-startCount) / elapsed.Seconds()
+				log.Printf("Inserted %d cards (%.0f cards/sec) | %s | %s | %s | queued raw %d/%d, cards %d/%d\n",
+					cp.CardCount, rate,
+					decodeStage.report(elapsed), mapStage.report(elapsed), insertStage.report(elapsed),
+					len(rawCh), cap(rawCh), len(cardCh), cap(cardCh))
+			}
+		}
+		if advanced && ctx.Err() == nil {
+			if err := DB.Save(cp).Error; err != nil {
+				fail(fmt.Errorf("failed to save prime checkpoint: %w", err))
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	now := time.Now()
+	cp.CompletedAt = &now
+	if err := DB.Save(cp).Error; err != nil {
+		return fmt.Errorf("failed to mark prime checkpoint complete: %w", err)
+	}
+
+	elapsed := time.Since(startTime)
+	log.Printf("\nPriming complete! Inserted %d cards in %s (%.0f cards/sec, %d total)\n",
+		cp.CardCount-startCount, elapsed.Round(time.Second), float64(cp.CardCount-startCount)/elapsed.Seconds(), cp.CardCount)
+	log.Printf("Stage throughput: %s | %s | %s\n",
+		decodeStage.report(elapsed), mapStage.report(elapsed), insertStage.report(elapsed))
+	if n := skipped.Load(); n > 0 {
+		log.Printf("Skipped %d records missing fields required for %s (%v)\n", n, opts.BulkType, spec.required)
+	}
+	// New cards should be typeable right away
+	if spec.object == "card" {
+		if err := RefreshNameIndex(); err != nil {
+			log.Printf("Autocomplete: %v", err)
+		}
+	}
+	return nil
+}
+
+// loadPrimeCheckpoint returns the checkpoint to continue from. A fresh one is
+// returned when restarting, when the previous run finished, or when the file
+// has changed size since the checkpoint was written.
+func loadPrimeCheckpoint(opts PrimeOptions) (*models.PrimeCheckpoint, error) {
+	fresh := &models.PrimeCheckpoint{Source: opts.Source, SourceSize: opts.Size}
+	if opts.Source == "" {
+		return nil, errors.New("prime source is required for checkpointing")
+	}
+	if opts.Restart {
+		if err := DB.Where("source = ?", opts.Source).Delete(&models.PrimeCheckpoint{}).Error; err != nil {
+			return nil, fmt.Errorf("failed to clear prime checkpoint: %w", err)
+		}
+		return fresh, nil
+	}
+
+	var cp models.PrimeCheckpoint
+	err := DB.Where("source = ?", opts.Source).First(&cp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prime checkpoint: %w", err)
+	}
+
+	if cp.CompletedAt != nil {
+		log.Printf("Previous prime of %s completed at %s, starting fresh", opts.Source, cp.CompletedAt.Format(time.RFC3339))
+		return fresh, nil
+	}
+	if cp.SourceSize != opts.Size {
+		log.Printf("%s changed size since the last checkpoint (%d -> %d), starting fresh", opts.Source, cp.SourceSize, opts.Size)
+		return fresh, nil
+	}
+	return &cp, nil
+}
+
+// openPrimeStream positions a decoder at the checkpoint, just inside the
+// JSON array or between NDJSON lines. It returns the decoder and the offset
+// to add to its InputOffset to get a position in the original file.
+func openPrimeStream(file io.Reader, cp *models.PrimeCheckpoint, spec bulkSpec) (*json.Decoder, int64, error) {
+	seeker, canSeek := file.(io.Seeker)
+
+	reader := bufio.NewReader(file)
+	isArray, lead, err := peekIsArray(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if cp.ByteOffset == 0 || !canSeek {
+		decoder := json.NewDecoder(reader)
+		if isArray {
+			// Read opening bracket
+			if _, err := decoder.Token(); err != nil {
+				return nil, 0, fmt.Errorf("failed to read opening bracket: %w", err)
+			}
+		}
+		if cp.CardCount > 0 {
+			if err := skipPrimedRecords(decoder, cp, spec); err != nil {
+				return nil, 0, err
+			}
+		}
+		return decoder, lead, nil
+	}
+
+	if _, err := seeker.Seek(cp.ByteOffset, io.SeekStart); err != nil {
+		return nil, 0, fmt.Errorf("failed to seek to checkpoint offset %d: %w", cp.ByteOffset, err)
+	}
+	reader.Reset(file)
+
+	if !isArray {
+		// NDJSON needs no framing; the decoder skips the newline itself
+		return json.NewDecoder(reader), cp.ByteOffset, nil
+	}
+
+	// The checkpoint sits right after a card, so the stream continues with
+	// an element separator. Drop it and re-open the array for the decoder.
+	var skipped int64
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read after checkpoint offset %d: %w", cp.ByteOffset, err)
+		}
+		if b == ' ' || b == '\n' || b == '\r' || b == '\t' {
+			skipped++
+			continue
+		}
+		if b == ',' {
+			skipped++
+		} else {
+			reader.UnreadByte()
+		}
+		break
+	}
+
+	decoder := json.NewDecoder(io.MultiReader(strings.NewReader("["), reader))
+	if _, err := decoder.Token(); err != nil {
+		return nil, 0, fmt.Errorf("failed to resume array: %w", err)
+	}
+	// Subtract the synthetic bracket we prepended
+	return decoder, cp.ByteOffset + skipped - 1, nil
+}
+
+// skipPrimedRecords discards the records already covered by the checkpoint
+// when the input cannot seek, and checks the file still lines up with it.
+func skipPrimedRecords(decoder *json.Decoder, cp *models.PrimeCheckpoint, spec bulkSpec) error {
+	var last string
+	for i := int64(0); i < cp.CardCount; i++ {
+		if !decoder.More() {
+			return fmt.Errorf("file ended after %d records but checkpoint expects %d; rerun with --restart", i, cp.CardCount)
+		}
+		var record map[string]json.RawMessage
+		if err := decoder.Decode(&record); err != nil {
+			return fmt.Errorf("failed to skip record %d: %w", i+1, err)
+		}
+		last = ""
+		json.Unmarshal(record[spec.idField], &last)
+	}
+	if last != cp.LastCardID {
+		return fmt.Errorf("checkpoint expects %s at position %d but found %s; rerun with --restart",
+			cp.LastCardID, cp.CardCount, last)
+	}
+	return nil
+}
+
+// cardUpsertColumns are overwritten when a card is primed again. updated_at
+// and cached_at are bumped only when one of the others actually changed.
+var cardUpsertColumns = []string{
+	"oracle_id", "name", "mana_cost", "cmc",
+	"type_line", "oracle_text", "power", "toughness", "loyalty",
+	"colors", "color_identity", "keywords", "card_faces",
+	"image_uris", "legalities", "prices", "set_code", "set_name",
+	"collector_number", "rarity", "artist", "flavor_text",
+	"released_at", "lang", "layout",
+}
+
+// cardChanged is the DO UPDATE condition that skips rows whose content is
+// identical, so cards.updated_at marks when a card last really changed.
+var cardChanged = clause.Expr{SQL: "(cards." + strings.Join(cardUpsertColumns, ", cards.") +
+	") IS DISTINCT FROM (excluded." + strings.Join(cardUpsertColumns, ", excluded.") + ")"}
+
+// batchInsertCards upserts cards and, in the same transaction, queues the
+// oracle IDs of the ones that were new or changed for the graph relay.
+func batchInsertCards(db *gorm.DB, cards []*models.Card) error {
+	// Rows only take this stamp if the upsert actually writes them, which is
+	// how changed cards are told apart. Postgres keeps microseconds.
+	stamp := time.Now().Truncate(time.Microsecond)
+	ids := make([]string, len(cards))
+	for i, c := range cards {
+		c.UpdatedAt = stamp
+		ids[i] = c.ID
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Use Clauses with OnConflict to handle duplicates
+		// This will update existing records instead of failing
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}}, // Conflict on primary key
+			DoUpdates: clause.AssignmentColumns(append([]string{"updated_at", "cached_at"}, cardUpsertColumns...)),
+			Where:     clause.Where{Exprs: []clause.Expression{cardChanged}},
+		}).CreateInBatches(cards, len(cards)).Error
+		if err != nil {
+			return err
+		}
+		return enqueueChangedCards(tx, ids, stamp)
+	})
+}
+
+func batchInsertRulings(db *gorm.DB, rulings []*models.Ruling) error {
+	// Postgres rejects an upsert that touches the same row twice, and
+	// identical rulings can land in one batch, so drop duplicates first
+	seen := make(map[string]bool, len(rulings))
+	unique := rulings[:0]
+	for _, r := range rulings {
+		if !seen[r.ID] {
+			seen[r.ID] = true
+			unique = append(unique, r)
+		}
+	}
+	rulings = unique
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at"}),
+	}).CreateInBatches(rulings, len(rulings)).Error
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"go-backend/scryfall"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"gorm.io/gorm"
+)
+
+// StartBulkRefresh reads the Scryfall config and, if refreshing is enabled,
+// checks for a new bulk file now and then once every RefreshInterval until
+// ctx is cancelled.
+func StartBulkRefresh(ctx context.Context) {
+	cfg := config.ScryfallConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		log.Fatalf("Scryfall: Failed to parse config: %v", err)
+	}
+	if !cfg.RefreshEnabled {
+		return
+	}
+
+	bulkType, err := ParseBulkType(cfg.BulkType)
+	if err != nil {
+		log.Fatalf("Scryfall: %v", err)
+	}
+	source := scryfall.NewClient(cfg.BaseURL)
+
+	go func() {
+		ticker := time.NewTicker(cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := RefreshBulkData(ctx, source, bulkType, cfg.DownloadDir); err != nil {
+				log.Printf("Scryfall: bulk refresh failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	fmt.Printf("Scryfall: refreshing %s every %s from %s\n", bulkType, cfg.RefreshInterval, cfg.BaseURL)
+}
+
+// RefreshBulkData imports the bulk file of the given type if the manifest
+// lists one newer than the last import. Cards that changed are queued in
+// the outbox as they are written, so the relay re-syncs just those oracle
+// IDs in the graph. It reports whether an import happened.
+func RefreshBulkData(ctx context.Context, source scryfall.BulkSource, bulkType BulkType, downloadDir string) (bool, error) {
+	manifest, err := source.BulkManifest(ctx)
+	if err != nil {
+		return false, err
+	}
+	entry, ok := scryfall.FindBulk(manifest, string(bulkType))
+	if !ok {
+		return false, fmt.Errorf("bulk-data manifest has no %s entry", bulkType)
+	}
+
+	var last models.BulkImport
+	err = DB.Where("bulk_type = ?", string(bulkType)).First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("failed to load last bulk import: %w", err)
+	}
+	if err == nil && !entry.UpdatedAt.After(last.SourceUpdatedAt) {
+		log.Printf("Scryfall: %s is up to date (%s)", bulkType, last.SourceUpdatedAt.Format(time.RFC3339))
+		return false, nil
+	}
+
+	log.Printf("Scryfall: downloading %s updated %s", bulkType, entry.UpdatedAt.Format(time.RFC3339))
+	file, err := downloadBulk(ctx, source, entry, downloadDir)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	opts := PrimeOptions{
+		// Keyed by publish time so a crashed import resumes on the same file
+		Source:   fmt.Sprintf("scryfall:%s:%s", bulkType, entry.UpdatedAt.UTC().Format(time.RFC3339)),
+		Size:     info.Size(),
+		BulkType: bulkType,
+	}
+
+	// batchInsertCards stamps updated_at from this process's clock, as GORM
+	// does the checkpoint's created_at, so compare against the same clock.
+	// When resuming, cards changed before the crash count too.
+	importStart := time.Now().Truncate(time.Microsecond)
+	var cp models.PrimeCheckpoint
+	if DB.Where("source = ? AND completed_at IS NULL", opts.Source).First(&cp).Error == nil {
+		importStart = cp.CreatedAt
+	}
+	if err := PrimeDatabase(file, opts); err != nil {
+		return false, err
+	}
+
+	var changed []string
+	if bulkSpecs[bulkType].object == "card" {
+		changed, err = GetChangedOracleIDs(importStart)
+		if err != nil {
+			return false, fmt.Errorf("failed to list changed cards: %w", err)
+		}
+		log.Printf("Scryfall: %d oracle IDs changed, queued for Memgraph", len(changed))
+	}
+
+	record := models.BulkImport{
+		BulkType:        string(bulkType),
+		SourceUpdatedAt: entry.UpdatedAt,
+		DownloadURI:     entry.DownloadURI,
+		ChangedOracles:  int64(len(changed)),
+	}
+	if err := DB.Save(&record).Error; err != nil {
+		return true, fmt.Errorf("failed to record bulk import: %w", err)
+	}
+	return true, nil
+}
+
+// downloadBulk saves the bulk file to disk so priming can seek and resume.
+func downloadBulk(ctx context.Context, source scryfall.BulkSource, entry scryfall.BulkData, dir string) (*os.File, error) {
+	body, err := source.Download(ctx, entry.DownloadURI)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	file, err := os.CreateTemp(dir, entry.Type+"-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download file: %w", err)
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to download %s: %w", entry.DownloadURI, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go-backend/models"
+	"go-backend/scryfall"
+)
+
+// fixtureScryfall serves a bulk-data manifest with one default_cards entry
+// and the bulk file it points at, both swappable between requests.
+type fixtureScryfall struct {
+	*httptest.Server
+	mu        sync.Mutex
+	file      string
+	updatedAt time.Time
+	downloads int
+}
+
+func newFixtureScryfall(t *testing.T) *fixtureScryfall {
+	f := &fixtureScryfall{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bulk-data", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"object": "list",
+			"data": []scryfall.BulkData{{
+				Type:        string(BulkDefaultCards),
+				Name:        "Default Cards",
+				UpdatedAt:   f.updatedAt,
+				DownloadURI: f.URL + "/file/default-cards.json",
+				ContentType: "application/json",
+			}},
+		})
+	})
+	mux.HandleFunc("/file/default-cards.json", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.downloads++
+		http.ServeFile(w, r, f.file)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fixtureScryfall) publish(file string, updatedAt time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.file, f.updatedAt = file, updatedAt
+}
+
+func TestDownloadBulk(t *testing.T) {
+	fixture := newFixtureScryfall(t)
+	fixture.publish("testdata/refresh/cards-v1.json", time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	client := scryfall.NewClient(fixture.URL)
+
+	manifest, err := client.BulkManifest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := scryfall.FindBulk(manifest, string(BulkDefaultCards))
+	if !ok {
+		t.Fatalf("manifest %+v has no default_cards entry", manifest)
+	}
+	file, err := downloadBulk(context.Background(), client, entry, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	got, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/refresh/cards-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("downloaded %d bytes, want the %d byte fixture", len(got), len(want))
+	}
+}
+
+func TestRefreshBulkData(t *testing.T) {
+	openTestDB(t)
+	fixture := newFixtureScryfall(t)
+	client := scryfall.NewClient(fixture.URL)
+	ctx := context.Background()
+	published := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	refresh := func(wantImported bool, wantChanged int64) {
+		t.Helper()
+		imported, err := RefreshBulkData(ctx, client, BulkDefaultCards, t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported != wantImported {
+			t.Fatalf("imported = %v, want %v", imported, wantImported)
+		}
+		var record models.BulkImport
+		if err := DB.First(&record, "bulk_type = ?", string(BulkDefaultCards)).Error; err != nil {
+			t.Fatal(err)
+		}
+		if record.ChangedOracles != wantChanged {
+			t.Errorf("changed oracle IDs = %d, want %d", record.ChangedOracles, wantChanged)
+		}
+	}
+
+	// First import: every card is new
+	fixture.publish("testdata/refresh/cards-v1.json", published)
+	refresh(true, 2)
+	var cards int64
+	DB.Model(&models.Card{}).Count(&cards)
+	if cards != 2 {
+		t.Fatalf("cards after first import = %d, want 2", cards)
+	}
+
+	// Same manifest: nothing is downloaded
+	refresh(false, 2)
+	if fixture.downloads != 1 {
+		t.Errorf("downloads = %d, want 1", fixture.downloads)
+	}
+
+	// A newer file with one card's text changed re-syncs just that card
+	fixture.publish("testdata/refresh/cards-v2.json", published.Add(24*time.Hour))
+	refresh(true, 1)
+
+	var events []models.OutboxEvent
+	if err := DB.Order("id").Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, e := range events {
+		counts[e.OracleID]++
+	}
+	if counts["68954295-54e3-4303-a6bc-fc4547a4e3a3"] != 2 || counts["44623693-51d6-49ad-8cd7-140505caf02f"] != 1 {
+		t.Errorf("outbox events per oracle ID = %v, want Llanowar Elves queued twice and Lightning Bolt once", counts)
+	}
+}
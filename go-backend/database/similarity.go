@@ -0,0 +1,83 @@
+package database
+
+import (
+	"math"
+	"sort"
+)
+
+// Suggestion scores weight each attribute a card shares with the source by
+// how rare it is (IDF: log((cards+1)/(cards with it+1))) times how telling
+// its relationship is, then add a bonus that shrinks as the mana values
+// drift apart.
+const (
+	mechanicWeight = 3.0
+	keywordWeight  = 2.0
+	typeWeight     = 1.0
+	// cmcWeight is the bonus for an identical mana value; one apart earns
+	// half of it, two apart a third and so on
+	cmcWeight = 1.0
+)
+
+// attributeWeight is the Cypher for one shared attribute's weight, given
+// the relationship type as rel and the attribute node as attr.
+const attributeWeight = `CASE rel
+					WHEN 'PRODUCES' THEN $mechanicWeight
+					WHEN 'HAS_KEYWORD' THEN $keywordWeight
+					ELSE $typeWeight
+				END * log(toFloat($cards + 1) / (inDegree(attr) + 1))`
+
+// attributeKinds names the relationship each shared attribute came through.
+var attributeKinds = map[string]string{
+	"PRODUCES":    "mechanic",
+	"HAS_KEYWORD": "keyword",
+	"IS_TYPE":     "type",
+}
+
+// SimilarityReason is one part of a suggestion's score: an attribute shared
+// with the source card, or the mana value bonus (Kind "cmc").
+type SimilarityReason struct {
+	Kind         string  `json:"kind"`
+	Name         string  `json:"name"`
+	Contribution float64 `json:"contribution"`
+}
+
+// SimilarityExplanation says why a card was suggested. Shared is ordered by
+// contribution, largest first, and sums to Score give or take rounding.
+type SimilarityExplanation struct {
+	Score  float64            `json:"score"`
+	Shared []SimilarityReason `json:"shared"`
+	// Connections is how many deck cards a deck suggestion shares
+	// something with; unset for single-card suggestions
+	Connections int `json:"connections,omitempty"`
+}
+
+// scoreRound trims contributions to the four places the Cypher rounds
+// scores to, which keeps them stable whatever order Memgraph summed in.
+func scoreRound(x float64) float64 {
+	return math.Round(x*1e4) / 1e4
+}
+
+// explainSuggestion turns the shared attributes a suggestion query
+// collected, plus any extra parts of its score, into an explanation.
+func explainSuggestion(score float64, shared []interface{}, extra ...SimilarityReason) *SimilarityExplanation {
+	ex := &SimilarityExplanation{Score: score, Shared: make([]SimilarityReason, 0, len(shared)+len(extra))}
+	for _, s := range shared {
+		attr, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rel, _ := attr["kind"].(string)
+		name, _ := attr["name"].(string)
+		weight, _ := attr["weight"].(float64)
+		ex.Shared = append(ex.Shared, SimilarityReason{
+			Kind:         attributeKinds[rel],
+			Name:         name,
+			Contribution: scoreRound(weight),
+		})
+	}
+	ex.Shared = append(ex.Shared, extra...)
+	sort.SliceStable(ex.Shared, func(i, j int) bool {
+		return ex.Shared[i].Contribution > ex.Shared[j].Contribution
+	})
+	return ex
+}
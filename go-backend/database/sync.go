@@ -0,0 +1,163 @@
+package database
+
+import (
+	"fmt"
+	"go-backend/models"
+	"log"
+
+	"gorm.io/gorm/clause"
+)
+
+const graphSyncBatchSize = 1000
+
+// fetchOracleCards returns one row per functional card after the given
+// oracle ID, keyset-paginated. DISTINCT ON (oracle_id) with released_at DESC
+// gives the graph the most recent text/wording.
+func fetchOracleCards(afterOracleID string, limit int) ([]*models.Card, error) {
+	var cards []*models.Card
+	err := DB.Raw(`
+		SELECT DISTINCT ON (oracle_id) * FROM cards
+		WHERE oracle_id IS NOT NULL AND oracle_id > ? AND deleted_at IS NULL
+		ORDER BY oracle_id, released_at DESC
+		LIMIT ?`, afterOracleID, limit).Scan(&cards).Error
+	return cards, err
+}
+
+// syncChangedCards sends the cards whose graph fingerprint differs from the
+// last one synced (or all of them when force is set) and records the new
+// fingerprints. It returns how many cards were sent.
+func syncChangedCards(cards []*models.Card, force bool) (int, error) {
+	ids := make([]string, 0, len(cards))
+	for _, c := range cards {
+		if c.OracleID != nil {
+			ids = append(ids, *c.OracleID)
+		}
+	}
+
+	known := make(map[string]string, len(ids))
+	if !force && len(ids) > 0 {
+		var states []models.GraphSyncState
+		if err := DB.Where("oracle_id IN ?", ids).Find(&states).Error; err != nil {
+			return 0, fmt.Errorf("failed to load graph sync state: %w", err)
+		}
+		for _, st := range states {
+			known[st.OracleID] = st.Fingerprint
+		}
+	}
+
+	var batchData []map[string]interface{}
+	var changed []models.GraphSyncState
+	for _, c := range cards {
+		if c.OracleID == nil {
+			continue
+		}
+		card := newGraphCard(c)
+		fp := card.fingerprint()
+		if !force && known[*c.OracleID] == fp {
+			continue
+		}
+		batchData = append(batchData, card.data())
+		changed = append(changed, models.GraphSyncState{OracleID: *c.OracleID, Fingerprint: fp})
+	}
+	if len(batchData) == 0 {
+		return 0, nil
+	}
+
+	if err := writeGraphBatch(batchData); err != nil {
+		return 0, fmt.Errorf("failed to sync batch to Memgraph: %w", err)
+	}
+
+	// Only record fingerprints once the graph has them
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "oracle_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fingerprint", "synced_at"}),
+	}).Create(&changed).Error
+	if err != nil {
+		return len(changed), fmt.Errorf("failed to save graph sync state: %w", err)
+	}
+	return len(changed), nil
+}
+
+// syncAllOracleCards walks every functional card in Postgres
+func syncAllOracleCards(force bool) (int, error) {
+	total := GetPostgresCardCount()
+	var seen, sent int
+	after := ""
+	for {
+		cards, err := fetchOracleCards(after, graphSyncBatchSize)
+		if err != nil {
+			return sent, fmt.Errorf("failed to fetch distinct cards: %w", err)
+		}
+		if len(cards) == 0 {
+			break
+		}
+		n, err := syncChangedCards(cards, force)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+		seen += len(cards)
+		after = *cards[len(cards)-1].OracleID
+
+		log.Printf("Checked %d/%d unique cards, %d sent to Memgraph...", seen, total, sent)
+	}
+	return sent, nil
+}
+
+// ReSyncToMemgraph rebuilds every card node, regardless of fingerprints.
+func ReSyncToMemgraph() error {
+	sent, err := syncAllOracleCards(true)
+	if err != nil {
+		return err
+	}
+	removed, err := pruneDeletedFromMemgraph()
+	if err != nil {
+		return err
+	}
+
+	log.Printf(" Memgraph re-sync complete (%d unique functional cards, %d removed).", sent, removed)
+	return nil
+}
+
+// IncrementalSyncToMemgraph sends only new cards and cards whose graph data
+// changed since their last sync, and removes nodes for cards that no longer
+// exist (or were soft-deleted) in Postgres.
+func IncrementalSyncToMemgraph() error {
+	sent, err := syncAllOracleCards(false)
+	if err != nil {
+		return err
+	}
+	removed, err := pruneDeletedFromMemgraph()
+	if err != nil {
+		return err
+	}
+
+	log.Printf(" Memgraph incremental sync complete (%d cards sent, %d removed).", sent, removed)
+	return nil
+}
+
+// pruneDeletedFromMemgraph removes graph nodes for synced oracle IDs that
+// have no live printing left in Postgres.
+func pruneDeletedFromMemgraph() (int, error) {
+	var gone []string
+	err := DB.Raw(`
+		SELECT s.oracle_id FROM graph_sync_states s
+		WHERE NOT EXISTS (
+			SELECT 1 FROM cards c
+			WHERE c.oracle_id = s.oracle_id AND c.deleted_at IS NULL
+		)`).Scan(&gone).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find deleted cards: %w", err)
+	}
+
+	for i := 0; i < len(gone); i += graphSyncBatchSize {
+		end := min(i+graphSyncBatchSize, len(gone))
+		if err := deleteFromMemgraph(gone[i:end]); err != nil {
+			return i, fmt.Errorf("failed to remove deleted cards from Memgraph: %w", err)
+		}
+		if err := DB.Where("oracle_id IN ?", gone[i:end]).Delete(&models.GraphSyncState{}).Error; err != nil {
+			return i, fmt.Errorf("failed to clear graph sync state: %w", err)
+		}
+	}
+	return len(gone), nil
+}
@@ -0,0 +1,130 @@
+package decklist
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"go-backend/models"
+)
+
+// csvColumns is the CSV export's header. Prices are per copy; reading a
+// CSV back only needs quantity and name, the other columns are optional.
+var csvColumns = []string{
+	"board", "quantity", "name", "set", "collector_number", "card_id", "oracle_id",
+	"usd", "usd_foil", "eur", "tix",
+}
+
+// csvPrices picks each price column out of Prices.
+var csvPrices = map[string]func(p *models.Prices) **models.Decimal{
+	"usd":      func(p *models.Prices) **models.Decimal { return &p.USD },
+	"usd_foil": func(p *models.Prices) **models.Decimal { return &p.USDFoil },
+	"eur":      func(p *models.Prices) **models.Decimal { return &p.EUR },
+	"tix":      func(p *models.Prices) **models.Decimal { return &p.Tix },
+}
+
+func writeCSV(w io.Writer, list *List) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, e := range sortedEntries(list) {
+		row := []string{
+			string(e.Board), strconv.Itoa(e.Quantity), e.Name, e.Set, e.CollectorNumber,
+			e.CardID, e.OracleID,
+		}
+		for _, col := range csvColumns[len(row):] {
+			price := ""
+			if e.Prices != nil {
+				if d := *csvPrices[col](e.Prices); d != nil {
+					price = d.String()
+				}
+			}
+			row = append(row, price)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// isCSV reports whether text starts with a header naming at least the
+// quantity and name columns.
+func isCSV(text string) bool {
+	first, _, _ := strings.Cut(text, "\n")
+	cols := map[string]bool{}
+	for _, c := range strings.Split(strings.ToLower(first), ",") {
+		cols[strings.TrimSpace(c)] = true
+	}
+	return cols["quantity"] && cols["name"]
+}
+
+func parseCSV(text string) (*List, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV decklist: %w", err)
+	}
+	index := map[string]int{}
+	for i, c := range rows[0] {
+		index[strings.ToLower(strings.TrimSpace(c))] = i
+	}
+	field := func(row []string, col string) string {
+		if i, ok := index[col]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	list := &List{}
+	for i, row := range rows[1:] {
+		line := i + 2
+		text := strings.Join(row, ",")
+		board, err := models.ParseBoard(field(row, "board"))
+		if err != nil {
+			list.Problems = append(list.Problems, Problem{Line: line, Text: text, Msg: err.Error()})
+			continue
+		}
+		quantity, err := strconv.Atoi(field(row, "quantity"))
+		if err != nil || quantity <= 0 {
+			list.Problems = append(list.Problems, Problem{Line: line, Text: text, Msg: "quantity must be a positive number"})
+			continue
+		}
+		entry := Entry{
+			Quantity:        quantity,
+			Name:            normalizeName(field(row, "name")),
+			Set:             strings.ToLower(field(row, "set")),
+			CollectorNumber: field(row, "collector_number"),
+			CardID:          field(row, "card_id"),
+			OracleID:        field(row, "oracle_id"),
+			Board:           board,
+			Line:            line,
+			Text:            text,
+		}
+		if entry.Name == "" && entry.CardID == "" && entry.OracleID == "" {
+			list.Problems = append(list.Problems, Problem{Line: line, Text: text, Msg: "missing card name"})
+			continue
+		}
+		for col, price := range csvPrices {
+			raw := field(row, col)
+			if raw == "" {
+				continue
+			}
+			d, err := models.ParseDecimal(raw)
+			if err != nil {
+				continue
+			}
+			if entry.Prices == nil {
+				entry.Prices = &models.Prices{}
+			}
+			*price(entry.Prices) = &d
+		}
+		list.Entries = append(list.Entries, entry)
+	}
+	return list, nil
+}
@@ -0,0 +1,186 @@
+package decklist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"go-backend/models"
+)
+
+// ExportFormat names a format Export can write.
+type ExportFormat string
+
+const (
+	// ExportArena is MTG Arena text, with set codes and collector numbers
+	// for pinned entries.
+	ExportArena ExportFormat = "arena"
+	// ExportMTGO is MTGO text: the main deck, a blank line and the
+	// sideboard. It has no commander or maybe board, so commanders go in
+	// the sideboard and the maybe board is left out.
+	ExportMTGO ExportFormat = "mtgo"
+	// ExportCSV is one row per entry with ids and per-copy prices.
+	ExportCSV ExportFormat = "csv"
+	// ExportJSON is the canonical form and the only lossless one.
+	ExportJSON ExportFormat = "json"
+)
+
+// ExportFormats lists every export format.
+var ExportFormats = []ExportFormat{ExportArena, ExportMTGO, ExportCSV, ExportJSON}
+
+// ParseExportFormat accepts an export format name, ignoring case.
+func ParseExportFormat(s string) (ExportFormat, error) {
+	f := ExportFormat(strings.ToLower(strings.TrimSpace(s)))
+	if slices.Contains(ExportFormats, f) {
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q", s)
+}
+
+// ContentType is the MIME type to serve an export with.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportJSON:
+		return "application/json"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Extension is the file extension for a download, without the dot.
+func (f ExportFormat) Extension() string {
+	switch f {
+	case ExportCSV, ExportJSON:
+		return string(f)
+	default:
+		return "txt"
+	}
+}
+
+// Export writes list in format f. Entries come out board by board in the
+// order of models.Boards, keeping their order within a board. Parse reads
+// every format back; what the text formats can't say is noted on each.
+func Export(w io.Writer, list *List, f ExportFormat) error {
+	switch f {
+	case ExportArena:
+		return writeArena(w, list)
+	case ExportMTGO:
+		return writeMTGO(w, list)
+	case ExportCSV:
+		return writeCSV(w, list)
+	case ExportJSON:
+		return writeJSON(w, list)
+	}
+	return fmt.Errorf("unknown export format %q", f)
+}
+
+// FromDeck turns a saved deck, with its entries' cards attached, into a
+// list to export. The attached card supplies names and prices, and the
+// printing only for pinned entries: an unpinned entry's card is just its
+// newest printing, and writing that out would pin it on import.
+func FromDeck(deck *models.Deck) *List {
+	list := &List{Name: deck.Name, Format: string(deck.Format)}
+	for _, e := range deck.Entries {
+		entry := Entry{Quantity: e.Quantity, Board: e.Board, OracleID: e.OracleID, Name: e.OracleID}
+		c := e.Card
+		if c != nil {
+			entry.Name, entry.Prices, entry.Layout = c.Name, c.Prices, c.Layout
+		}
+		if e.CardID != nil {
+			entry.CardID = *e.CardID
+			if c != nil {
+				entry.Set = strings.ToLower(c.SetCode)
+				if c.CollectorNumber != nil {
+					entry.CollectorNumber = *c.CollectorNumber
+				}
+			}
+		}
+		list.Entries = append(list.Entries, entry)
+	}
+	return list
+}
+
+// sortedEntries groups list's entries by board.
+func sortedEntries(list *List) []Entry {
+	entries := slices.Clone(list.Entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return slices.Index(models.Boards, entries[i].Board) < slices.Index(models.Boards, entries[j].Board)
+	})
+	return entries
+}
+
+// joinedLayouts are the layouts Arena and MTGO name by both halves, "Fire //
+// Ice" and "Fire/Ice". They name transform, modal double-faced, adventure
+// and flip cards by their front face alone.
+var joinedLayouts = []string{"split", "aftermath"}
+
+// clientName is the name Arena and MTGO know the entry's card by. Without a
+// layout, as for a parsed list, the name is kept as written.
+func clientName(e Entry) string {
+	if e.Layout == "" || slices.Contains(joinedLayouts, e.Layout) {
+		return e.Name
+	}
+	front, _, _ := strings.Cut(e.Name, " // ")
+	return front
+}
+
+// arenaSections are the headings Arena text uses for each board.
+var arenaSections = map[models.Board]string{
+	models.BoardCommander: "Commander",
+	models.BoardMain:      "Deck",
+	models.BoardSide:      "Sideboard",
+	models.BoardMaybe:     "Maybeboard",
+}
+
+func writeArena(w io.Writer, list *List) error {
+	bw := bufio.NewWriter(w)
+	var sections []string
+	if list.Name != "" {
+		sections = append(sections, "About\nName "+list.Name+"\n")
+	}
+	for _, board := range models.Boards {
+		var b strings.Builder
+		for _, e := range list.Entries {
+			if e.Board != board {
+				continue
+			}
+			fmt.Fprintf(&b, "%d %s", e.Quantity, clientName(e))
+			if e.Set != "" {
+				fmt.Fprintf(&b, " (%s)", strings.ToUpper(e.Set))
+				if e.CollectorNumber != "" {
+					fmt.Fprintf(&b, " %s", e.CollectorNumber)
+				}
+			}
+			b.WriteString("\n")
+		}
+		if b.Len() > 0 {
+			sections = append(sections, arenaSections[board]+"\n"+b.String())
+		}
+	}
+	bw.WriteString(strings.Join(sections, "\n"))
+	return bw.Flush()
+}
+
+func writeMTGO(w io.Writer, list *List) error {
+	bw := bufio.NewWriter(w)
+	var main, side strings.Builder
+	for _, e := range sortedEntries(list) {
+		line := fmt.Sprintf("%d %s\n", e.Quantity, strings.ReplaceAll(clientName(e), " // ", "/"))
+		switch e.Board {
+		case models.BoardMain:
+			main.WriteString(line)
+		case models.BoardSide, models.BoardCommander:
+			side.WriteString(line)
+		}
+	}
+	bw.WriteString(main.String())
+	if side.Len() > 0 {
+		bw.WriteString("\n" + side.String())
+	}
+	return bw.Flush()
+}
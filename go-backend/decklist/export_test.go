@@ -0,0 +1,164 @@
+package decklist
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"go-backend/models"
+)
+
+// goldenCase is a decklist and what exporting it in Format must give:
+// Want, or the text itself when Want is empty, which makes the case a
+// round trip.
+type goldenCase struct {
+	Name   string       `json:"name"`
+	Format ExportFormat `json:"format"`
+	Text   string       `json:"text"`
+	Want   string       `json:"want,omitempty"`
+}
+
+// TestGolden parses and exports every case in testdata/golden.json, then
+// parses and exports the result again, which must not change it either.
+func TestGolden(t *testing.T) {
+	data, err := os.ReadFile("testdata/golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []goldenCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			want := c.Want
+			if want == "" {
+				want = c.Text
+			}
+			if got := roundTrip(t, c.Text, c.Format); got != want {
+				t.Fatalf("export gave\n%s\nwant\n%s", got, want)
+			}
+			if got := roundTrip(t, want, c.Format); got != want {
+				t.Fatalf("re-export gave\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func roundTrip(t *testing.T, text string, f ExportFormat) string {
+	t.Helper()
+	list, err := Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range list.Problems {
+		t.Fatalf("line %d %q: %s", p.Line, p.Text, p.Msg)
+	}
+	var out strings.Builder
+	if err := Export(&out, list, f); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+// TestSavedDeckKeepsPinning exports a saved deck in every format and reads
+// it back: the pinned entry must keep its printing, and the unpinned one,
+// whose attached card is only its newest printing, must not gain one.
+func TestSavedDeckKeepsPinning(t *testing.T) {
+	str := func(s string) *string { return &s }
+	boltID := "2b7e3e29-4e5e-4a4b-8b6a-2c4e3d1f0a9b"
+	deck := &models.Deck{
+		Name: "Burn",
+		Entries: []models.DeckEntry{
+			{
+				Board: models.BoardMain, Quantity: 4, CardID: &boltID,
+				OracleID: "4457ed35-7c10-48c8-9776-456485fdf070",
+				Card: &models.Card{
+					ID: boltID, Name: "Lightning Bolt", SetCode: "M10", CollectorNumber: str("146"),
+				},
+			},
+			{
+				Board: models.BoardMain, Quantity: 4,
+				OracleID: "0b1ebd4f-5ef2-4c47-a4f5-2e9d7c3b1a6e",
+				Card: &models.Card{
+					ID: "9c1f0f8e-1d2b-4c3a-8e7f-6a5b4c3d2e1f", Name: "Lava Spike",
+					SetCode: "MH3", CollectorNumber: str("301"),
+				},
+			},
+		},
+	}
+
+	for _, f := range ExportFormats {
+		t.Run(string(f), func(t *testing.T) {
+			var out strings.Builder
+			if err := Export(&out, FromDeck(deck), f); err != nil {
+				t.Fatal(err)
+			}
+			list, err := Parse(out.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list.Entries) != 2 {
+				t.Fatalf("entries = %+v\nfrom\n%s", list.Entries, out.String())
+			}
+			pinned, unpinned := list.Entries[0], list.Entries[1]
+			if f != ExportMTGO && (pinned.Set != "m10" || pinned.CollectorNumber != "146") {
+				t.Errorf("pinned entry lost its printing: %+v", pinned)
+			}
+			if unpinned.Set != "" || unpinned.CollectorNumber != "" || unpinned.CardID != "" {
+				t.Errorf("unpinned entry was pinned: %+v", unpinned)
+			}
+		})
+	}
+}
+
+// TestExportNamesCardsByLayout checks Arena and MTGO get the names their
+// importers know: both halves for split and aftermath cards, the front
+// face for everything else.
+func TestExportNamesCardsByLayout(t *testing.T) {
+	card := func(name, layout string) models.DeckEntry {
+		return models.DeckEntry{
+			Board: models.BoardMain, Quantity: 1, OracleID: name,
+			Card: &models.Card{Name: name, Layout: layout},
+		}
+	}
+	deck := &models.Deck{Entries: []models.DeckEntry{
+		card("Fire // Ice", "split"),
+		card("Commit // Memory", "aftermath"),
+		card("Delver of Secrets // Insectile Aberration", "transform"),
+		card("Bala Ged Recovery // Bala Ged Sanctuary", "modal_dfc"),
+		card("Brazen Borrower // Petty Theft", "adventure"),
+		card("Bushi Tenderfoot // Kenzo the Hardhearted", "flip"),
+		card("Lightning Bolt", "normal"),
+		// an older row without a layout keeps its name
+		card("Wear // Tear", ""),
+	}}
+
+	tests := map[ExportFormat]string{
+		ExportArena: "Deck\n1 Fire // Ice\n1 Commit // Memory\n1 Delver of Secrets\n1 Bala Ged Recovery\n" +
+			"1 Brazen Borrower\n1 Bushi Tenderfoot\n1 Lightning Bolt\n1 Wear // Tear\n",
+		ExportMTGO: "1 Fire/Ice\n1 Commit/Memory\n1 Delver of Secrets\n1 Bala Ged Recovery\n" +
+			"1 Brazen Borrower\n1 Bushi Tenderfoot\n1 Lightning Bolt\n1 Wear/Tear\n",
+	}
+	for f, want := range tests {
+		var out strings.Builder
+		if err := Export(&out, FromDeck(deck), f); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != want {
+			t.Errorf("%s export gave\n%s\nwant\n%s", f, out.String(), want)
+		}
+	}
+
+	// the canonical forms keep the full name
+	list := FromDeck(deck)
+	var out strings.Builder
+	if err := Export(&out, list, ExportJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"name": "Delver of Secrets // Insectile Aberration"`) {
+		t.Errorf("JSON lost the full name:\n%s", out.String())
+	}
+}
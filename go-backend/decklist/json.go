@@ -0,0 +1,79 @@
+package decklist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go-backend/models"
+)
+
+// jsonDeck is the canonical JSON form. It carries everything a deck has,
+// so it round-trips exactly. An entry pinned to a printing names it by
+// card_id or by set and collector_number; an unpinned one has none of them
+// and is just its oracle_id.
+type jsonDeck struct {
+	Name    string      `json:"name,omitempty"`
+	Format  string      `json:"format,omitempty"`
+	Entries []jsonEntry `json:"entries"`
+}
+
+type jsonEntry struct {
+	Board           models.Board `json:"board"`
+	Quantity        int          `json:"quantity"`
+	Name            string       `json:"name"`
+	Set             string       `json:"set,omitempty"`
+	CollectorNumber string       `json:"collector_number,omitempty"`
+	CardID          string       `json:"card_id,omitempty"`
+	OracleID        string       `json:"oracle_id,omitempty"`
+}
+
+func writeJSON(w io.Writer, list *List) error {
+	deck := jsonDeck{Name: list.Name, Format: list.Format, Entries: []jsonEntry{}}
+	for _, e := range sortedEntries(list) {
+		deck.Entries = append(deck.Entries, jsonEntry{
+			Board:           e.Board,
+			Quantity:        e.Quantity,
+			Name:            e.Name,
+			Set:             e.Set,
+			CollectorNumber: e.CollectorNumber,
+			CardID:          e.CardID,
+			OracleID:        e.OracleID,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(deck)
+}
+
+func parseJSON(text string) (*List, error) {
+	var deck jsonDeck
+	if err := json.Unmarshal([]byte(text), &deck); err != nil {
+		return nil, fmt.Errorf("reading JSON decklist: %w", err)
+	}
+	list := &List{Name: deck.Name, Format: deck.Format}
+	for i, e := range deck.Entries {
+		text := fmt.Sprintf("%d %s", e.Quantity, e.Name)
+		board, err := models.ParseBoard(string(e.Board))
+		if err != nil {
+			list.Problems = append(list.Problems, Problem{Line: i + 1, Text: text, Msg: err.Error()})
+			continue
+		}
+		if e.Quantity <= 0 || (e.Name == "" && e.CardID == "" && e.OracleID == "") {
+			list.Problems = append(list.Problems, Problem{Line: i + 1, Text: text, Msg: "missing quantity or card"})
+			continue
+		}
+		list.Entries = append(list.Entries, Entry{
+			Quantity:        e.Quantity,
+			Name:            normalizeName(e.Name),
+			Set:             e.Set,
+			CollectorNumber: e.CollectorNumber,
+			CardID:          e.CardID,
+			OracleID:        e.OracleID,
+			Board:           board,
+			Line:            i + 1,
+			Text:            text,
+		})
+	}
+	return list, nil
+}
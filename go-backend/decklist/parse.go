@@ -0,0 +1,252 @@
+// Package decklist reads and writes decklists: MTGO .dek files and text
+// exports, MTG Arena exports such as `4 Lightning Bolt (M10) 146`, plain
+// "1x Name" lists with sideboard sections, and our own CSV and JSON. It
+// only deals in text; looking the cards up is the database package's job.
+package decklist
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go-backend/models"
+)
+
+// ErrEmpty is returned for a list with nothing in it.
+var ErrEmpty = errors.New("decklist is empty")
+
+// Entry is one card line. Set and CollectorNumber are only known when the
+// line names a printing; Set is lower-cased like Scryfall's set codes.
+// CardID and OracleID come from our CSV and JSON, which carry them.
+type Entry struct {
+	Quantity        int
+	Name            string
+	Set             string
+	CollectorNumber string
+	CardID          string
+	OracleID        string
+	Board           models.Board
+	Line            int // 1-based line, or card position in a .dek file
+	Text            string
+
+	// Prices is only read by the CSV export
+	Prices *models.Prices
+	// Layout is the card's Scryfall layout, when it came from a saved deck;
+	// the text exports name a card by it (see clientName)
+	Layout string
+}
+
+// Problem is a line that looked like a card but couldn't be read.
+type Problem struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	Msg  string `json:"message"`
+}
+
+// List is a parsed decklist. Name and Format are set when the list
+// carries them.
+type List struct {
+	Name     string
+	Format   string
+	Entries  []Entry
+	Problems []Problem
+}
+
+// sectionHeaders maps the headings exports put above each board. Companions
+// live in the sideboard.
+var sectionHeaders = map[string]models.Board{
+	"deck": models.BoardMain, "main": models.BoardMain, "maindeck": models.BoardMain,
+	"mainboard": models.BoardMain, "main deck": models.BoardMain,
+	"sideboard": models.BoardSide, "side": models.BoardSide, "companion": models.BoardSide,
+	"commander": models.BoardCommander, "commanders": models.BoardCommander,
+	"maybeboard": models.BoardMaybe, "maybe": models.BoardMaybe, "considering": models.BoardMaybe,
+}
+
+// groupHeaders are the card type headings some sites group a board by.
+// They keep the board, except that they end a commander section.
+var groupHeaders = map[string]bool{
+	"creature": true, "creatures": true, "instant": true, "instants": true,
+	"sorcery": true, "sorceries": true, "artifact": true, "artifacts": true,
+	"enchantment": true, "enchantments": true, "planeswalker": true, "planeswalkers": true,
+	"battle": true, "battles": true, "land": true, "lands": true, "spells": true, "other": true,
+}
+
+var (
+	// headerCount strips the card count some exports add: "Sideboard (15)"
+	headerCount = regexp.MustCompile(`\s*\(\d+\)$`)
+	// cardLine is "[SB:] [4[x]] Name [(SET) [number]] [*F*]"; sets may be
+	// bracketed either way
+	cardLine = regexp.MustCompile(`^(?i:SB:\s*)?(?:(\d+)\s*[xX]?\s+)?(.+?)` +
+		`(?:\s+[(\[]([A-Za-z0-9]{2,6})[)\]](?:\s+([^\s*]+))?)?` +
+		`(?:\s+\*[A-Za-z]+\*)*$`)
+	// splitNames catches Arena's "Fire /// Ice" and the bare "Fire/Ice"
+	splitNames = regexp.MustCompile(`\s*/{1,3}\s*`)
+)
+
+// Parse reads a decklist in any of the supported formats. Lines that can't
+// be read are reported in Problems rather than failing the whole list.
+func Parse(text string) (*List, error) {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(text, "\ufeff"))
+	if trimmed == "" {
+		return nil, ErrEmpty
+	}
+	var list *List
+	var err error
+	switch {
+	case strings.HasPrefix(trimmed, "<"):
+		list, err = parseDek(trimmed)
+	case strings.HasPrefix(trimmed, "{"):
+		list, err = parseJSON(trimmed)
+	case isCSV(trimmed):
+		list, err = parseCSV(trimmed)
+	default:
+		list = parseText(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Entries) == 0 && len(list.Problems) == 0 {
+		return nil, ErrEmpty
+	}
+	return list, nil
+}
+
+// parseDek reads an MTGO .dek file, which is XML with one Cards element
+// per card and board.
+func parseDek(text string) (*List, error) {
+	var dek struct {
+		Cards []struct {
+			Quantity  int    `xml:"Quantity,attr"`
+			Sideboard bool   `xml:"Sideboard,attr"`
+			Name      string `xml:"Name,attr"`
+		} `xml:"Cards"`
+	}
+	if err := xml.Unmarshal([]byte(text), &dek); err != nil {
+		return nil, fmt.Errorf("reading .dek file: %w", err)
+	}
+	list := &List{}
+	for i, c := range dek.Cards {
+		text := fmt.Sprintf("%d %s", c.Quantity, c.Name)
+		if c.Quantity <= 0 || strings.TrimSpace(c.Name) == "" {
+			list.Problems = append(list.Problems, Problem{Line: i + 1, Text: text, Msg: "missing quantity or name"})
+			continue
+		}
+		board := models.BoardMain
+		if c.Sideboard {
+			board = models.BoardSide
+		}
+		list.Entries = append(list.Entries, Entry{
+			Quantity: c.Quantity,
+			Name:     normalizeName(c.Name),
+			Board:    board,
+			Line:     i + 1,
+			Text:     text,
+		})
+	}
+	return list, nil
+}
+
+// parseText reads the line-based formats. Section headers pick the board;
+// a list without any follows MTGO and starts the sideboard at the first
+// blank line after some cards.
+func parseText(text string) *List {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	hasHeaders := false
+	for _, line := range lines {
+		if _, ok := header(line); ok {
+			hasHeaders = true
+			break
+		}
+	}
+
+	list := &List{}
+	board := models.BoardMain
+	inAbout := false
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "":
+			inAbout = false
+			if !hasHeaders && len(list.Entries) > 0 {
+				board = models.BoardSide
+			}
+			continue
+		case strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#"):
+			continue
+		case strings.EqualFold(line, "about"):
+			// Arena puts the deck's name in an About block
+			inAbout = true
+			continue
+		}
+		if inAbout {
+			if name, ok := strings.CutPrefix(line, "Name "); ok {
+				list.Name = strings.TrimSpace(name)
+			}
+			continue
+		}
+		if b, ok := header(line); ok {
+			board = b
+			continue
+		}
+		if groupHeaders[headerKey(line)] {
+			if board == models.BoardCommander {
+				board = models.BoardMain
+			}
+			continue
+		}
+
+		entry, err := parseLine(line)
+		if err != nil {
+			list.Problems = append(list.Problems, Problem{Line: i + 1, Text: line, Msg: err.Error()})
+			continue
+		}
+		entry.Board, entry.Line, entry.Text = board, i+1, line
+		if len(line) > 3 && strings.EqualFold(line[:3], "SB:") {
+			entry.Board = models.BoardSide
+		}
+		list.Entries = append(list.Entries, entry)
+	}
+	return list
+}
+
+// header reports whether line is a section heading such as "Sideboard",
+// "SIDEBOARD:" or "Commander (1)", and which board it starts.
+func header(line string) (models.Board, bool) {
+	b, ok := sectionHeaders[headerKey(line)]
+	return b, ok
+}
+
+// headerKey lower-cases a possible heading and drops its colon and count.
+func headerKey(line string) string {
+	key := strings.ToLower(strings.TrimSpace(line))
+	key = strings.TrimSuffix(headerCount.ReplaceAllString(key, ""), ":")
+	return strings.TrimSpace(key)
+}
+
+func parseLine(line string) (Entry, error) {
+	m := cardLine.FindStringSubmatch(line)
+	if m == nil {
+		return Entry{}, errors.New("not a card line")
+	}
+	entry := Entry{Quantity: 1, Name: normalizeName(m[2]), Set: strings.ToLower(m[3]), CollectorNumber: m[4]}
+	if m[1] != "" {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n <= 0 {
+			return Entry{}, errors.New("quantity must be a positive number")
+		}
+		entry.Quantity = n
+	}
+	if entry.Name == "" {
+		return Entry{}, errors.New("missing card name")
+	}
+	return entry, nil
+}
+
+// normalizeName writes split and double-faced names the way the cards
+// table does, "Fire // Ice".
+func normalizeName(name string) string {
+	return splitNames.ReplaceAllString(strings.TrimSpace(name), " // ")
+}
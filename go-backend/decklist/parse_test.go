@@ -0,0 +1,149 @@
+package decklist
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go-backend/models"
+)
+
+func TestParse(t *testing.T) {
+	const (
+		main  = models.BoardMain
+		side  = models.BoardSide
+		cmdr  = models.BoardCommander
+		maybe = models.BoardMaybe
+	)
+	tests := []struct {
+		name     string
+		text     string
+		listName string
+		entries  []Entry
+		problems []Problem
+	}{
+		{
+			name: "MTGO text: the first blank line starts the sideboard",
+			text: "4 Lightning Bolt\n2 Fire/Ice\n\n3 Pyroblast\n\n1 Red Elemental Blast\n",
+			entries: []Entry{
+				{Quantity: 4, Name: "Lightning Bolt", Board: main, Line: 1, Text: "4 Lightning Bolt"},
+				{Quantity: 2, Name: "Fire // Ice", Board: main, Line: 2, Text: "2 Fire/Ice"},
+				{Quantity: 3, Name: "Pyroblast", Board: side, Line: 4, Text: "3 Pyroblast"},
+				{Quantity: 1, Name: "Red Elemental Blast", Board: side, Line: 6, Text: "1 Red Elemental Blast"},
+			},
+		},
+		{
+			name: "blank lines don't switch boards when there are headers",
+			text: "Deck\n4 Lightning Bolt\n\n2 Shock\nSideboard\n1 Pyroblast",
+			entries: []Entry{
+				{Quantity: 4, Name: "Lightning Bolt", Board: main, Line: 2, Text: "4 Lightning Bolt"},
+				{Quantity: 2, Name: "Shock", Board: main, Line: 4, Text: "2 Shock"},
+				{Quantity: 1, Name: "Pyroblast", Board: side, Line: 6, Text: "1 Pyroblast"},
+			},
+		},
+		{
+			name: "SB: lines go to the sideboard",
+			text: "4 Lightning Bolt\nSB: 2 Pyroblast\nsb:1 Smash to Smithereens\r\n",
+			entries: []Entry{
+				{Quantity: 4, Name: "Lightning Bolt", Board: main, Line: 1, Text: "4 Lightning Bolt"},
+				{Quantity: 2, Name: "Pyroblast", Board: side, Line: 2, Text: "SB: 2 Pyroblast"},
+				{Quantity: 1, Name: "Smash to Smithereens", Board: side, Line: 3, Text: "sb:1 Smash to Smithereens"},
+			},
+		},
+		{
+			name: "printings in round or square brackets",
+			text: "4 Lightning Bolt (M10) 146\n1 Opt [XLN] 65\n2 Shock [M19]\n" +
+				"1 Kess, Dissident Mage (MH1) 200 *F*\n4x Counterspell\n1 Fire /// Ice (MH2) 290\nBrainstorm",
+			entries: []Entry{
+				{Quantity: 4, Name: "Lightning Bolt", Set: "m10", CollectorNumber: "146", Board: main, Line: 1,
+					Text: "4 Lightning Bolt (M10) 146"},
+				{Quantity: 1, Name: "Opt", Set: "xln", CollectorNumber: "65", Board: main, Line: 2, Text: "1 Opt [XLN] 65"},
+				{Quantity: 2, Name: "Shock", Set: "m19", Board: main, Line: 3, Text: "2 Shock [M19]"},
+				{Quantity: 1, Name: "Kess, Dissident Mage", Set: "mh1", CollectorNumber: "200", Board: main, Line: 4,
+					Text: "1 Kess, Dissident Mage (MH1) 200 *F*"},
+				{Quantity: 4, Name: "Counterspell", Board: main, Line: 5, Text: "4x Counterspell"},
+				{Quantity: 1, Name: "Fire // Ice", Set: "mh2", CollectorNumber: "290", Board: main, Line: 6,
+					Text: "1 Fire /// Ice (MH2) 290"},
+				{Quantity: 1, Name: "Brainstorm", Board: main, Line: 7, Text: "Brainstorm"},
+			},
+		},
+		{
+			name:     "Arena About block, commander and type groups",
+			text:     "About\nName Kess Tempo\n\nCommander\n1 Kess, Dissident Mage\nCreatures (1)\n1 Delver of Secrets // Insectile Aberration\nSideboard (1)\n1 Opt\nMAYBEBOARD:\n1 Ponder",
+			listName: "Kess Tempo",
+			entries: []Entry{
+				{Quantity: 1, Name: "Kess, Dissident Mage", Board: cmdr, Line: 5, Text: "1 Kess, Dissident Mage"},
+				{Quantity: 1, Name: "Delver of Secrets // Insectile Aberration", Board: main, Line: 7,
+					Text: "1 Delver of Secrets // Insectile Aberration"},
+				{Quantity: 1, Name: "Opt", Board: side, Line: 9, Text: "1 Opt"},
+				{Quantity: 1, Name: "Ponder", Board: maybe, Line: 11, Text: "1 Ponder"},
+			},
+		},
+		{
+			name: "comments and a byte order mark are skipped",
+			text: "\ufeff// Burn\n# by someone\n4 Lightning Bolt",
+			entries: []Entry{
+				{Quantity: 4, Name: "Lightning Bolt", Board: main, Line: 3, Text: "4 Lightning Bolt"},
+			},
+		},
+		{
+			name: "unreadable lines are problems, not failures",
+			text: "4 Lightning Bolt\n0 Shock\n99999999999999999999 Opt\n1 Ponder",
+			entries: []Entry{
+				{Quantity: 4, Name: "Lightning Bolt", Board: main, Line: 1, Text: "4 Lightning Bolt"},
+				{Quantity: 1, Name: "Ponder", Board: main, Line: 4, Text: "1 Ponder"},
+			},
+			problems: []Problem{
+				{Line: 2, Text: "0 Shock", Msg: "quantity must be a positive number"},
+				{Line: 3, Text: "99999999999999999999 Opt", Msg: "quantity must be a positive number"},
+			},
+		},
+		{
+			name: "MTGO .dek",
+			text: `<?xml version="1.0" encoding="utf-8"?>
+<Deck xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+  <NetDeckID>0</NetDeckID>
+  <Cards CatID="1" Quantity="4" Sideboard="false" Name="Lightning Bolt" />
+  <Cards CatID="2" Quantity="2" Sideboard="true" Name="Fire/Ice" />
+  <Cards CatID="3" Quantity="0" Sideboard="false" Name="Opt" />
+  <Cards CatID="4" Quantity="1" Sideboard="false" Name=" " />
+</Deck>`,
+			entries: []Entry{
+				{Quantity: 4, Name: "Lightning Bolt", Board: main, Line: 1, Text: "4 Lightning Bolt"},
+				{Quantity: 2, Name: "Fire // Ice", Board: side, Line: 2, Text: "2 Fire/Ice"},
+			},
+			problems: []Problem{
+				{Line: 3, Text: "0 Opt", Msg: "missing quantity or name"},
+				{Line: 4, Text: "1  ", Msg: "missing quantity or name"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := Parse(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if list.Name != tt.listName {
+				t.Errorf("name = %q, want %q", list.Name, tt.listName)
+			}
+			if !reflect.DeepEqual(list.Entries, tt.entries) {
+				t.Errorf("entries\n got %+v\nwant %+v", list.Entries, tt.entries)
+			}
+			if !reflect.DeepEqual(list.Problems, tt.problems) {
+				t.Errorf("problems\n got %+v\nwant %+v", list.Problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{"", " \n\t", "// just a comment\n# and another", "Deck\nSideboard\n"} {
+		if list, err := Parse(text); !errors.Is(err, ErrEmpty) {
+			t.Errorf("%q: got %+v, %v; want ErrEmpty", text, list, err)
+		}
+	}
+	if list, err := Parse("<Deck><Cards Quantity=\"4\""); err == nil {
+		t.Errorf("broken .dek: got %+v, want an error", list)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"go-backend/database"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RequireAdmin only lets requests carrying "Authorization: Bearer <token>"
+// through to next.
+func RequireAdmin(token string, next http.HandlerFunc) http.HandlerFunc {
+	want := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// GetParity reports cards missing from, extra in, or out of date in the graph
+func GetParity(w http.ResponseWriter, r *http.Request) {
+	report, err := database.CheckParity()
+	if err != nil {
+		http.Error(w, "Parity check failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// DeleteCard soft-deletes the printing with the given Scryfall ID; the
+// outbox relay then drops it from the graph if it was the last printing.
+func DeleteCard(w http.ResponseWriter, r *http.Request) {
+	err := database.DeleteCard(mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, database.ErrCardNotFound):
+		http.Error(w, "Card not found", http.StatusNotFound)
+	case err != nil:
+		http.Error(w, "Database error", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -3,11 +3,9 @@ package handlers
 import (
 	"encoding/json"
 	"go-backend/database"
-	"go-backend/models"
 	"io"
 	"net/http"
-
-	"github.com/lib/pq"
+	"strconv"
 )
 
 func GetCardID(w http.ResponseWriter, r *http.Request){
@@ -30,6 +28,77 @@ func GetCardID(w http.ResponseWriter, r *http.Request){
 }
 
 
+// SearchCards answers GET /api/cards/search?q=t:creature c>=rg cmc<=3
+func SearchCards(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+
+	req, err := parsePageRequest(r, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseCardFilter(r)
+	if err != nil {
+		writeCardPage(w, nil, err)
+		return
+	}
+
+	page, err := database.SearchCards(query, filter, req)
+	writeCardPage(w, page, err)
+}
+
+// SearchOracleText answers GET /api/cards/text?q="draw a card" sacrifice* -discard
+// with cards ranked by full-text relevance.
+func SearchOracleText(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+	req, err := parsePageRequest(r, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseCardFilter(r)
+	if err != nil {
+		writeCardPage(w, nil, err)
+		return
+	}
+
+	page, err := database.SearchOracleFullText(query, filter, req)
+	writeCardPage(w, page, err)
+}
+
+// AutocompleteCards answers GET /api/cards/autocomplete?prefix=lim-d with
+// matching English card names, e.g. {"data": ["Lim-Dûl the Necromancer"]}.
+func AutocompleteCards(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		http.Error(w, "Prefix is required", http.StatusBadRequest)
+		return
+	}
+	limit := 10
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > 50 {
+			http.Error(w, "limit must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": database.CompleteCardName(prefix, limit),
+	})
+}
+
 func GetRndCard(w http.ResponseWriter, r *http.Request) {
 
 	// Check if card already exists in database
@@ -69,16 +138,20 @@ func GetSimilarCards(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Use the data
-	cards, err := database.SearchFuzzyOracleText(requestData.Name, requestData.OracleTexts)
+	req, err := parsePageRequest(r, 50)
 	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseCardFilter(r)
+	if err != nil {
+		writeCardPage(w, nil, err)
 		return
 	}
 
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(cards)
+	// Use the data
+	page, err := database.SearchFuzzyOracleText(requestData.Name, requestData.OracleTexts, filter, req)
+	writeCardPage(w, page, err)
 }
 
 func MemSuggest(w http.ResponseWriter, r *http.Request){
@@ -103,16 +176,20 @@ func MemSuggest(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	// Use the data
-	cards, err := database.GetCardSuggestions(requestData.OracleID)
+	req, err := parsePageRequest(r, 10)
 	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseCardFilter(r)
+	if err != nil {
+		writeCardPage(w, nil, err)
 		return
 	}
 
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(cards)
+	// Use the data
+	page, err := database.GetCardSuggestions(requestData.OracleID, filter, req)
+	writeCardPage(w, page, err)
 }
 
 func CardVariants(w http.ResponseWriter, r *http.Request){
@@ -137,16 +214,15 @@ func CardVariants(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	// Use the data
-	cards, err := database.GetCardVariants(requestData.OracleID, requestData.ID)
+	req, err := parsePageRequest(r, 50)
 	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(cards)
+	// Use the data
+	page, err := database.GetCardVariants(requestData.OracleID, requestData.ID, req)
+	writeCardPage(w, page, err)
 }
 
 func GetFuzzyCard(w http.ResponseWriter, r *http.Request){
@@ -155,115 +231,21 @@ func GetFuzzyCard(w http.ResponseWriter, r *http.Request){
 		http.Error(w, "Card name is required", http.StatusBadRequest)
 		return
 	}
-		card, err := database.SearchCardByNameFuzzy(cardName)
-	if err == nil {
-		// Card found in database, return cached version
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"data": card,
-		})
+	req, err := parsePageRequest(r, 10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-}
-
-// mapScryfallToCard converts Scryfall JSON to our Card model
-func mapScryfallToCard(data map[string]interface{}) *models.Card {
-	card := &models.Card{}
-
-	// Required fields
-	if id, ok := data["id"].(string); ok {
-		card.ID = id
-	}
-	if name, ok := data["name"].(string); ok {
-		card.Name = name
-	}
-	if typeLine, ok := data["type_line"].(string); ok {
-		card.TypeLine = typeLine
-	}
-	if rarity, ok := data["rarity"].(string); ok {
-		card.Rarity = rarity
-	}
-	if setCode, ok := data["set"].(string); ok {
-		card.SetCode = setCode
-	}
-
-	// Optional fields
-	if oracleID, ok := data["oracle_id"].(string); ok {
-		card.OracleID = &oracleID
-	}
-	if manaCost, ok := data["mana_cost"].(string); ok {
-		card.ManaCost = &manaCost
-	}
-	if cmc, ok := data["cmc"].(float64); ok {
-		card.CMC = &cmc
-	}
-	if oracleText, ok := data["oracle_text"].(string); ok {
-		card.OracleText = &oracleText
-	}
-	if power, ok := data["power"].(string); ok {
-		card.Power = &power
-	}
-	if toughness, ok := data["toughness"].(string); ok {
-		card.Toughness = &toughness
-	}
-	if loyalty, ok := data["loyalty"].(string); ok {
-		card.Loyalty = &loyalty
-	}
-
-	// Arrays - Updated to use pq.StringArray
-	if colors, ok := data["colors"].([]interface{}); ok {
-		card.Colors = make(pq.StringArray, 0, len(colors))
-		for _, c := range colors {
-			if color, ok := c.(string); ok {
-				card.Colors = append(card.Colors, color)
-			}
-		}
-	}
-
-	if colorIdentity, ok := data["color_identity"].([]interface{}); ok {
-		card.ColorIdentity = make(pq.StringArray, 0, len(colorIdentity))
-		for _, c := range colorIdentity {
-			if color, ok := c.(string); ok {
-				card.ColorIdentity = append(card.ColorIdentity, color)
-			}
-		}
-	}
-
-	// Handle keywords if present in the data
-	if keywords, ok := data["keywords"].([]interface{}); ok {
-		card.Keywords = make(pq.StringArray, 0, len(keywords))
-		for _, k := range keywords {
-			if keyword, ok := k.(string); ok {
-				card.Keywords = append(card.Keywords, keyword)
-			}
-		}
-	}
-
-	// Store complex objects as JSON strings
-	if imageURIs, ok := data["image_uris"].(map[string]interface{}); ok {
-		if jsonBytes, err := json.Marshal(imageURIs); err == nil {
-			jsonStr := string(jsonBytes)
-			card.ImageURIs = &jsonStr
-		}
-	}
-	if legalities, ok := data["legalities"].(map[string]interface{}); ok {
-		if jsonBytes, err := json.Marshal(legalities); err == nil {
-			jsonStr := string(jsonBytes)
-			card.Legalities = &jsonStr
-		}
-	}
-	if prices, ok := data["prices"].(map[string]interface{}); ok {
-		if jsonBytes, err := json.Marshal(prices); err == nil {
-			jsonStr := string(jsonBytes)
-			card.Prices = &jsonStr
-		}
+	filter, err := parseCardFilter(r)
+	if err != nil {
+		writeCardPage(w, nil, err)
+		return
 	}
 
-	return card
+	page, err := database.SearchCardByNameFuzzy(cardName, filter, req)
+	writeCardPage(w, page, err)
 }
+
 func OptionsHandler(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Access-Control-Allow-Origin", "*")
     w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
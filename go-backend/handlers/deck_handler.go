@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go-backend/database"
+	"go-backend/decklist"
+	"go-backend/models"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// deckID reads the {id} path variable.
+func deckID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		return 0, database.ErrDeckNotFound
+	}
+	return uint(id), nil
+}
+
+// decodeDeck reads a deck from the request body, answering 400 if it can't.
+func decodeDeck(w http.ResponseWriter, r *http.Request) (*models.Deck, bool) {
+	defer r.Body.Close()
+	var deck models.Deck
+	if err := json.NewDecoder(r.Body).Decode(&deck); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	return &deck, true
+}
+
+// writeDeckResult sends v as JSON with status, or maps err to a status.
+func writeDeckResult(w http.ResponseWriter, status int, v interface{}, err error) {
+	switch {
+	case errors.Is(err, database.ErrDeckNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, database.ErrInvalidDeck), errors.Is(err, database.ErrCardNotFound),
+		errors.Is(err, database.ErrInvalidPage):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if v == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// ListDecks answers GET /api/decks?limit=&cursor= with saved decks, newest
+// first, without their entries.
+func ListDecks(w http.ResponseWriter, r *http.Request) {
+	req, err := parsePageRequest(r, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := database.ListDecks(req.Limit, req.Cursor)
+	writeDeckResult(w, http.StatusOK, page, err)
+}
+
+// CreateDeck answers POST /api/decks. The body is a deck:
+// {"name", "format", "description", "entries": [{"board", "quantity",
+// "card_id" or "oracle_id"}]}.
+func CreateDeck(w http.ResponseWriter, r *http.Request) {
+	deck, ok := decodeDeck(w, r)
+	if !ok {
+		return
+	}
+	saved, err := database.CreateDeck(deck)
+	writeDeckResult(w, http.StatusCreated, saved, err)
+}
+
+// GetDeck answers GET /api/decks/{id} with the deck and its cards.
+func GetDeck(w http.ResponseWriter, r *http.Request) {
+	id, err := deckID(r)
+	if err != nil {
+		writeDeckResult(w, 0, nil, err)
+		return
+	}
+	deck, err := database.GetDeck(id)
+	writeDeckResult(w, http.StatusOK, deck, err)
+}
+
+// UpdateDeck answers PUT /api/decks/{id}, replacing the deck with the body.
+func UpdateDeck(w http.ResponseWriter, r *http.Request) {
+	id, err := deckID(r)
+	if err != nil {
+		writeDeckResult(w, 0, nil, err)
+		return
+	}
+	deck, ok := decodeDeck(w, r)
+	if !ok {
+		return
+	}
+	saved, err := database.UpdateDeck(id, deck)
+	writeDeckResult(w, http.StatusOK, saved, err)
+}
+
+// DeleteDeck answers DELETE /api/decks/{id}.
+func DeleteDeck(w http.ResponseWriter, r *http.Request) {
+	id, err := deckID(r)
+	if err != nil {
+		writeDeckResult(w, 0, nil, err)
+		return
+	}
+	writeDeckResult(w, http.StatusNoContent, nil, database.DeleteDeck(id))
+}
+
+// maxStatsCards caps the copies posted to AnalyzeCards
+const maxStatsCards = 1000
+
+// maxImportBytes caps a pasted decklist; a 250-card cube export is well
+// under it
+const maxImportBytes = 256 << 10
+
+// ImportDeck answers POST /api/decks/import with {"text", "name", "format"},
+// parsing an MTGO, Arena or plain text decklist into an unsaved deck plus
+// the lines that couldn't be matched to a card.
+func ImportDeck(w http.ResponseWriter, r *http.Request) {
+	var requestData struct {
+		Text   string `json:"text"`
+		Name   string `json:"name"`
+		Format string `json:"format"`
+	}
+	defer r.Body.Close()
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBytes)).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	var format models.Format
+	if requestData.Format != "" {
+		var err error
+		if format, err = models.ParseFormat(requestData.Format); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := database.ImportDecklist(requestData.Text, requestData.Name, format)
+	writeDeckResult(w, http.StatusOK, result, err)
+}
+
+// ExportDeck answers GET /api/decks/{id}/export?format= with the deck as a
+// download in arena (the default), mtgo, csv or json format.
+func ExportDeck(w http.ResponseWriter, r *http.Request) {
+	id, err := deckID(r)
+	if err != nil {
+		writeDeckResult(w, 0, nil, err)
+		return
+	}
+	format := decklist.ExportArena
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		if format, err = decklist.ParseExportFormat(raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	deck, err := database.GetDeck(id)
+	if err != nil {
+		writeDeckResult(w, 0, nil, err)
+		return
+	}
+
+	var out bytes.Buffer
+	if err := decklist.Export(&out, decklist.FromDeck(deck), format); err != nil {
+		http.Error(w, "Export failed", http.StatusInternalServerError)
+		return
+	}
+	filename := deck.Name + "." + format.Extension()
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Write(out.Bytes())
+}
+
+// GetDeckStats answers GET /api/decks/{id}/stats with the deck's mana
+// curve, pips, types, mechanics, land advice and price.
+func GetDeckStats(w http.ResponseWriter, r *http.Request) {
+	id, err := deckID(r)
+	if err != nil {
+		writeDeckResult(w, 0, nil, err)
+		return
+	}
+	stats, err := database.AnalyzeDeck(id)
+	writeDeckResult(w, http.StatusOK, stats, err)
+}
+
+// AnalyzeCards answers POST /api/decks/stats with {"cards": ["<scryfall id>"]}:
+// the same stats as GetDeckStats for an unsaved list, one copy per ID given.
+func AnalyzeCards(w http.ResponseWriter, r *http.Request) {
+	var requestData struct {
+		Cards []string `json:"cards"`
+	}
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(requestData.Cards) == 0 {
+		http.Error(w, "Cards are required", http.StatusBadRequest)
+		return
+	}
+	if len(requestData.Cards) > maxStatsCards {
+		http.Error(w, "Too many cards", http.StatusBadRequest)
+		return
+	}
+	stats, err := database.AnalyzeCards(requestData.Cards)
+	writeDeckResult(w, http.StatusOK, stats, err)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/database"
+	"go-backend/models"
+	"net/http"
+)
+
+// maxLegalityCards caps one check; a 250-card cube is the largest list
+// anyone should need
+const maxLegalityCards = 250
+
+// CheckLegality answers POST /api/legality/check with
+// {"cards": ["Lightning Bolt", "<scryfall id>"], "formats": ["modern"]},
+// reporting banned, restricted and not-legal cards per format. Leaving out
+// formats checks every format.
+func CheckLegality(w http.ResponseWriter, r *http.Request) {
+	var requestData struct {
+		Cards   []string `json:"cards"`
+		Formats []string `json:"formats"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if len(requestData.Cards) == 0 {
+		http.Error(w, "Cards are required", http.StatusBadRequest)
+		return
+	}
+	if len(requestData.Cards) > maxLegalityCards {
+		http.Error(w, "Too many cards", http.StatusBadRequest)
+		return
+	}
+	formats := make([]models.Format, 0, len(requestData.Formats))
+	for _, raw := range requestData.Formats {
+		format, err := models.ParseFormat(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		formats = append(formats, format)
+	}
+
+	report, err := database.CheckLegality(requestData.Cards, formats)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
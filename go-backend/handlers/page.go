@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-backend/database"
+	"go-backend/models"
+	"go-backend/search"
+	"net/http"
+	"strconv"
+)
+
+// parsePageRequest reads limit, cursor, sort and dir from the query string.
+// POST endpoints take them there too, alongside their JSON body.
+func parsePageRequest(r *http.Request, defaultLimit int) (database.PageRequest, error) {
+	q := r.URL.Query()
+	req := database.PageRequest{
+		Limit:  defaultLimit,
+		Cursor: q.Get("cursor"),
+		Sort:   database.SortField(q.Get("sort")),
+		Dir:    q.Get("dir"),
+	}
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return req, errors.New("limit must be a number")
+		}
+		req.Limit = n
+	}
+	return req, nil
+}
+
+// errBadFilter marks filter parameters the client got wrong.
+var errBadFilter = errors.New("invalid filter")
+
+// parseCardFilter reads the optional filters from the query string:
+// format, and a colour identity given either as identity=gu or as the
+// commander whose identity to use (commander=<name or Scryfall ID>).
+func parseCardFilter(r *http.Request) (database.CardFilter, error) {
+	var filter database.CardFilter
+	q := r.URL.Query()
+	if raw := q.Get("format"); raw != "" {
+		format, err := models.ParseFormat(raw)
+		if err != nil {
+			return filter, fmt.Errorf("%w: %v", errBadFilter, err)
+		}
+		filter.Format = format
+	}
+
+	identity, commander := q.Get("identity"), q.Get("commander")
+	var err error
+	switch {
+	case identity != "" && commander != "":
+		return filter, fmt.Errorf("%w: use identity or commander, not both", errBadFilter)
+	case identity != "":
+		if filter.Identity, err = database.ParseIdentity(identity); err != nil {
+			err = fmt.Errorf("%w: %v", errBadFilter, err)
+		}
+	case commander != "":
+		filter.Identity, err = database.CommanderIdentity(commander)
+	}
+	return filter, err
+}
+
+// writeCardPage sends a page of cards, or maps err to a status: bad paging,
+// filters and queries are the client's fault, anything else is ours.
+func writeCardPage(w http.ResponseWriter, page *database.CardPage, err error) {
+	var queryErr *search.Error
+	switch {
+	case errors.Is(err, database.ErrInvalidPage), errors.Is(err, database.ErrCardNotFound),
+		errors.Is(err, errBadFilter):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.As(err, &queryErr):
+		http.Error(w, queryErr.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/database"
+	"net/http"
+)
+
+// maxDeckSuggestCards caps the deck sent to DeckSuggest, matching the
+// legality check
+const maxDeckSuggestCards = maxLegalityCards
+
+// DeckSuggest answers POST /api/cards/mems/deck with {"oracle_ids": [...]},
+// recommending cards for the deck as a whole. It takes the same paging and
+// format, identity and commander parameters as MemSuggest.
+func DeckSuggest(w http.ResponseWriter, r *http.Request) {
+	var requestData struct {
+		OracleIDs []string `json:"oracle_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if len(requestData.OracleIDs) == 0 {
+		http.Error(w, "Oracle IDs are required", http.StatusBadRequest)
+		return
+	}
+	if len(requestData.OracleIDs) > maxDeckSuggestCards {
+		http.Error(w, "Too many cards", http.StatusBadRequest)
+		return
+	}
+
+	req, err := parsePageRequest(r, 10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseCardFilter(r)
+	if err != nil {
+		writeCardPage(w, nil, err)
+		return
+	}
+
+	page, err := database.GetDeckSuggestions(requestData.OracleIDs, filter, req)
+	writeCardPage(w, page, err)
+}
@@ -1,34 +1,54 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 
+	"go-backend/config"
 	"go-backend/database"
 	"go-backend/handlers"
 	"go-backend/models"
 
+	"github.com/caarlos0/env/v11"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 )
 
 func main() {
+
 	//load .env
 	err := godotenv.Load()
     if err != nil {
         log.Fatal("Error loading .env file")
     }
+	appModels := []interface{}{
+		&models.Card{}, &models.PrimeCheckpoint{}, &models.Ruling{},
+		&models.BulkImport{}, &models.GraphSyncState{}, &models.OutboxEvent{},
+		&models.Deck{}, &models.DeckEntry{},
+	}
+
+	// `parity` is a one-off check: connect, report and exit without serving
+	if len(os.Args) > 1 && os.Args[1] == "parity" {
+		database.InitializeDatabase(appModels...)
+		database.InitializeMemgraph()
+		os.Exit(runParity())
+	}
+
 	// 1. Initialize the database connection and run migrations
-	database. InitSystem(&models.Card{})
+	database. InitSystem(appModels...)
 	// 2. Check if we should prime the database in the background
 	if len(os.Args) > 1 && os.Args[1] == "prime" {
-		filePath := "../../all-cards.json"
-		if len(os.Args) > 2 {
-			filePath = os.Args[2]
+		args, err := parsePrimeArgs(os.Args[2:])
+		if err != nil {
+			log.Fatalf("prime: %v", err)
 		}
 
 		// Start priming in a goroutine
@@ -39,14 +59,29 @@ func main() {
 			defer wg.Done()
 			fmt.Println("Starting database priming in background...")
 
-			file, err := os.Open(filePath)
+			file, err := os.Open(args.FilePath)
 			if err != nil {
-				log.Printf("Failed to open JSON file '%s': %v", filePath, err)
+				log.Printf("Failed to open bulk file '%s': %v", args.FilePath, err)
 				return
 			}
 			defer file.Close()
 
-			if err := database.PrimeDatabase(file); err != nil {
+			opts := database.PrimeOptions{
+				Source:        args.FilePath,
+				Size:          -1,
+				Restart:       args.Restart,
+				BulkType:      args.BulkType,
+				MapWorkers:    args.MapWorkers,
+				InsertWorkers: args.InsertWorkers,
+			}
+			if abs, err := filepath.Abs(args.FilePath); err == nil {
+				opts.Source = abs
+			}
+			if info, err := file.Stat(); err == nil {
+				opts.Size = info.Size()
+			}
+
+			if err := database.PrimeDatabase(file, opts); err != nil {
 				log.Printf("Failed to prime database: %v", err)
 				return
 			}
@@ -56,6 +91,11 @@ func main() {
 
 	}
 
+	// Push card changes queued in the outbox through to Memgraph
+	database.StartOutboxRelay(context.Background())
+	// Keep the cards table current with Scryfall if SCRYFALL_REFRESH is set
+	database.StartBulkRefresh(context.Background())
+
 	// 3. Setup the router
 	router := mux.NewRouter()
 
@@ -65,7 +105,21 @@ func main() {
 	router.HandleFunc("/api/cards/fuzzy",handlers.GetFuzzyCard).Methods("GET")
 	router.HandleFunc("/api/cards/id",handlers.GetCardID).Methods("GET")
 	router.HandleFunc("/api/cards/mems", handlers.MemSuggest).Methods("POST")
+	router.HandleFunc("/api/cards/mems/deck", handlers.DeckSuggest).Methods("POST")
 	router.HandleFunc("/api/cards/variants", handlers.CardVariants).Methods("POST")
+	router.HandleFunc("/api/cards/search", handlers.SearchCards).Methods("GET")
+	router.HandleFunc("/api/cards/autocomplete", handlers.AutocompleteCards).Methods("GET")
+	router.HandleFunc("/api/cards/text", handlers.SearchOracleText).Methods("GET")
+	router.HandleFunc("/api/legality/check", handlers.CheckLegality).Methods("POST")
+	router.HandleFunc("/api/decks", handlers.ListDecks).Methods("GET")
+	router.HandleFunc("/api/decks", handlers.CreateDeck).Methods("POST")
+	router.HandleFunc("/api/decks/import", handlers.ImportDeck).Methods("POST")
+	router.HandleFunc("/api/decks/stats", handlers.AnalyzeCards).Methods("POST")
+	router.HandleFunc("/api/decks/{id}", handlers.GetDeck).Methods("GET")
+	router.HandleFunc("/api/decks/{id}", handlers.UpdateDeck).Methods("PUT")
+	router.HandleFunc("/api/decks/{id}", handlers.DeleteDeck).Methods("DELETE")
+	router.HandleFunc("/api/decks/{id}/export", handlers.ExportDeck).Methods("GET")
+	router.HandleFunc("/api/decks/{id}/stats", handlers.GetDeckStats).Methods("GET")
 
 
 	router.PathPrefix("/").HandlerFunc(handlers.OptionsHandler).Methods("OPTIONS")
@@ -79,10 +133,122 @@ func main() {
 	})
 
 	// Wrap router with CORS middleware
-	handler := c.Handler(router)
+	handler := http.NewServeMux()
+	handler.Handle("/", c.Handler(router))
+
+	// Admin routes need ADMIN_TOKEN and skip the CORS middleware, so other
+	// origins can't call them from a browser
+	adminCfg := config.AdminConfig{}
+	if err := env.Parse(&adminCfg); err != nil {
+		log.Fatalf("Admin: Failed to parse config: %v", err)
+	}
+	if adminCfg.Token != "" {
+		admin := mux.NewRouter()
+		admin.HandleFunc("/api/admin/parity", handlers.RequireAdmin(adminCfg.Token, handlers.GetParity)).Methods("GET")
+		admin.HandleFunc("/api/admin/cards/{id}", handlers.RequireAdmin(adminCfg.Token, handlers.DeleteCard)).Methods("DELETE")
+		handler.Handle("/api/admin/", admin)
+	} else {
+		fmt.Println("Admin: ADMIN_TOKEN not set, admin endpoints disabled")
+	}
 
 	// 5. Start the server
 	port := "8081"
 	fmt.Printf("Server listening on http://localhost:%s\n", port)
 	log.Fatal(http.ListenAndServe(":"+port, handler))
 }
+
+type primeArgs struct {
+	FilePath      string
+	Restart       bool
+	BulkType      database.BulkType
+	MapWorkers    int
+	InsertWorkers int
+}
+
+// parsePrimeArgs reads
+// `prime [--restart] [--bulk-type T] [--workers N] [--inserters N] [file]`. Flags may come before or
+// after the file path.
+func parsePrimeArgs(args []string) (primeArgs, error) {
+	parsed := primeArgs{FilePath: "../../all-cards.json"}
+
+	fs := flag.NewFlagSet("prime", flag.ContinueOnError)
+	fs.BoolVar(&parsed.Restart, "restart", false, "ignore any saved checkpoint and prime from the start")
+	bulkType := fs.String("bulk-type", string(database.BulkAllCards), "oracle-cards, unique-artwork, default-cards, all-cards or rulings")
+	fs.IntVar(&parsed.MapWorkers, "workers", 0, "goroutines mapping cards (default: number of CPUs)")
+	fs.IntVar(&parsed.InsertWorkers, "inserters", 0, "goroutines inserting batches into Postgres (default: 4)")
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return parsed, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	var err error
+	if parsed.BulkType, err = database.ParseBulkType(*bulkType); err != nil {
+		return parsed, err
+	}
+	if len(positional) > 1 {
+		return parsed, fmt.Errorf("expected at most one file, got %v", positional)
+	}
+	if len(positional) == 1 {
+		parsed.FilePath = positional[0]
+	}
+	return parsed, nil
+}
+
+// runParity prints the Postgres/Memgraph parity report and returns the exit
+// code: 0 when in sync, 1 when the graph has drifted, 2 on error.
+func runParity() int {
+	report, err := database.CheckParity()
+	if err != nil {
+		log.Printf("Parity check failed: %v", err)
+		return 2
+	}
+
+	fmt.Printf("Counts -> Postgres (Unique Cards): %d | Memgraph (Nodes): %d\n", report.PostgresCards, report.MemgraphCards)
+	sections := []struct {
+		title   string
+		entries []database.ParityEntry
+	}{
+		{"Missing from Memgraph", report.Missing},
+		{"Extra in Memgraph", report.Extra},
+		{"Drifted", report.Drifted},
+	}
+	const maxListed = 20
+	for _, section := range sections {
+		fmt.Printf("%s: %d\n", section.title, len(section.entries))
+		for i, e := range section.entries {
+			if i == maxListed {
+				fmt.Printf("  ... and %d more\n", len(section.entries)-maxListed)
+				break
+			}
+			if len(e.Fields) > 0 {
+				fmt.Printf("  %s %s (%s)\n", e.OracleID, e.Name, strings.Join(e.Fields, ", "))
+			} else {
+				fmt.Printf("  %s %s\n", e.OracleID, e.Name)
+			}
+		}
+	}
+
+	// Dead events explain drift the relay could not fix on its own
+	fmt.Printf("Dead outbox events: %d\n", len(report.DeadEvents))
+	for i, e := range report.DeadEvents {
+		if i == maxListed {
+			fmt.Printf("  ... and %d more\n", len(report.DeadEvents)-maxListed)
+			break
+		}
+		fmt.Printf("  %s %s after %d attempts: %s\n", e.Op, e.OracleID, e.Attempts, e.LastError)
+	}
+
+	if report.InSync {
+		fmt.Println("Databases are in sync.")
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,202 @@
+package mechanics
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go-backend/oracle"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// SelfName is what a card's own name is replaced with before matching, so
+// rules can say "when CARDNAME enters" without knowing the card.
+const SelfName = oracle.SelfName
+
+// RuleSet is the on-disk rules format.
+type RuleSet struct {
+	// StripReminderText drops parenthesised reminder text before matching
+	StripReminderText bool   `json:"strip_reminder_text"`
+	Rules             []Rule `json:"rules"`
+}
+
+// Rule tags a card with Name when any pattern matches its oracle text,
+// unless the sentence containing the match also matches an exclude pattern.
+// Patterns are Go regular expressions and match case-insensitively.
+type Rule struct {
+	Name     string   `json:"name"`
+	Patterns []string `json:"patterns"`
+	Exclude  []string `json:"exclude,omitempty"`
+}
+
+type compiledRule struct {
+	name     string
+	patterns []*regexp.Regexp
+	exclude  []*regexp.Regexp
+}
+
+// Engine extracts mechanics from oracle text with a compiled RuleSet.
+type Engine struct {
+	stripReminder bool
+	rules         []compiledRule
+}
+
+// sentenceEnd splits oracle text into sentences and lines for exclusion checks
+var sentenceEnd = regexp.MustCompile(`[.\n]`)
+
+// Compile validates a RuleSet and compiles its patterns.
+func Compile(set RuleSet) (*Engine, error) {
+	e := &Engine{stripReminder: set.StripReminderText}
+	seen := make(map[string]bool)
+	for i, rule := range set.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i)
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("rule %q is defined twice", rule.Name)
+		}
+		seen[rule.Name] = true
+		if len(rule.Patterns) == 0 {
+			return nil, fmt.Errorf("rule %q has no patterns", rule.Name)
+		}
+
+		cr := compiledRule{name: rule.Name}
+		for _, p := range rule.Patterns {
+			re, err := regexp.Compile("(?i)" + p)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: bad pattern %q: %w", rule.Name, p, err)
+			}
+			cr.patterns = append(cr.patterns, re)
+		}
+		for _, p := range rule.Exclude {
+			re, err := regexp.Compile("(?i)" + p)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: bad exclude pattern %q: %w", rule.Name, p, err)
+			}
+			cr.exclude = append(cr.exclude, re)
+		}
+		e.rules = append(e.rules, cr)
+	}
+	return e, nil
+}
+
+// Load reads a JSON RuleSet.
+func Load(r io.Reader) (*Engine, error) {
+	var set RuleSet
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode mechanics rules: %w", err)
+	}
+	return Compile(set)
+}
+
+// LoadFile reads a JSON RuleSet from disk.
+func LoadFile(path string) (*Engine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Names lists the mechanics the engine can produce, in rule order.
+func (e *Engine) Names() []string {
+	names := make([]string, len(e.rules))
+	for i, r := range e.rules {
+		names[i] = r.name
+	}
+	return names
+}
+
+// Extract returns the mechanics found in a card's oracle text, in rule order.
+func (e *Engine) Extract(name, oracleText string) []string {
+	mechanics := []string{}
+	text := e.prepare(name, oracleText)
+	if text == "" {
+		return mechanics
+	}
+
+	for _, rule := range e.rules {
+		if rule.matches(text) {
+			mechanics = append(mechanics, rule.name)
+		}
+	}
+	return mechanics
+}
+
+// prepare strips reminder text and swaps the card's own name for SelfName
+// (see oracle.ReplaceSelfName).
+func (e *Engine) prepare(name, oracleText string) string {
+	text := oracleText
+	if e.stripReminder {
+		text = oracle.StripReminderText(text)
+	}
+	return strings.TrimSpace(oracle.ReplaceSelfName(name, text))
+}
+
+func (r compiledRule) matches(text string) bool {
+	for _, re := range r.patterns {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if !r.excluded(sentenceAround(text, loc[0], loc[1])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r compiledRule) excluded(sentence string) bool {
+	for _, re := range r.exclude {
+		if re.MatchString(sentence) {
+			return true
+		}
+	}
+	return false
+}
+
+// sentenceAround widens a match to the whole sentence(s) it spans
+func sentenceAround(text string, start, end int) string {
+	from := 0
+	if locs := sentenceEnd.FindAllStringIndex(text[:start], -1); len(locs) > 0 {
+		from = locs[len(locs)-1][1]
+	}
+	to := len(text)
+	if loc := sentenceEnd.FindStringIndex(text[end:]); loc != nil {
+		to = end + loc[1]
+	}
+	return text[from:to]
+}
+
+//go:embed rules.json
+var defaultRules []byte
+
+var (
+	defaultMu     sync.RWMutex
+	defaultEngine *Engine
+)
+
+func init() {
+	e, err := Load(strings.NewReader(string(defaultRules)))
+	if err != nil {
+		panic(fmt.Sprintf("mechanics: built-in rules are invalid: %v", err))
+	}
+	defaultEngine = e
+}
+
+// Default returns the engine used for graph sync: the built-in rules unless
+// SetDefault replaced them.
+func Default() *Engine {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultEngine
+}
+
+// SetDefault swaps the engine used for graph sync.
+func SetDefault(e *Engine) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultEngine = e
+}
@@ -0,0 +1,77 @@
+package mechanics
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// goldenCase is a real card and the mechanics it must be tagged with.
+type goldenCase struct {
+	Name       string   `json:"name"`
+	OracleText string   `json:"oracle_text"`
+	Mechanics  []string `json:"mechanics"`
+}
+
+// TestGolden runs the cards in testdata/golden.json through the built-in
+// rules, or the file MECHANICS_RULES_FILE names. Tags are compared as sets.
+func TestGolden(t *testing.T) {
+	engine := Default()
+	if path := os.Getenv("MECHANICS_RULES_FILE"); path != "" {
+		var err error
+		if engine, err = LoadFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile("testdata/golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []goldenCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			got := engine.Extract(c.Name, c.OracleText)
+			var missing, extra []string
+			for _, want := range c.Mechanics {
+				if !slices.Contains(got, want) {
+					missing = append(missing, want)
+				}
+			}
+			for _, g := range got {
+				if !slices.Contains(c.Mechanics, g) {
+					extra = append(extra, g)
+				}
+			}
+			sort.Strings(missing)
+			sort.Strings(extra)
+			if len(missing) > 0 || len(extra) > 0 {
+				t.Errorf("missing %v, unexpected %v", missing, extra)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsBadRules(t *testing.T) {
+	for name, rules := range map[string]string{
+		"not JSON":       `{"rules": [`,
+		"unnamed rule":   `{"rules": [{"patterns": ["draw"]}]}`,
+		"duplicate rule": `{"rules": [{"name": "Draw", "patterns": ["draw"]}, {"name": "Draw", "patterns": ["draws"]}]}`,
+		"no patterns":    `{"rules": [{"name": "Draw"}]}`,
+		"bad pattern":    `{"rules": [{"name": "Draw", "patterns": ["("]}]}`,
+		"bad exclude":    `{"rules": [{"name": "Draw", "patterns": ["draw"], "exclude": ["["]}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(strings.NewReader(rules)); err == nil {
+				t.Error("Load succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// BulkImport remembers the last Scryfall bulk file imported for each bulk
+// type, so the refresher only downloads a file when Scryfall publishes a
+// newer one.
+type BulkImport struct {
+	BulkType        string    `gorm:"primaryKey;type:varchar(50)"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime"`
+	SourceUpdatedAt time.Time `gorm:"not null"`
+	DownloadURI     string    `gorm:"type:varchar(1000)"`
+	ChangedOracles  int64     `gorm:"type:bigint;default:0"`
+}
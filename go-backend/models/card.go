@@ -1,7 +1,6 @@
 package models
 
 import (
-	"encoding/json"
 	"time"
 
 	"github.com/lib/pq"
@@ -29,11 +28,11 @@ type Card struct {
 	ColorIdentity pq.StringArray `gorm:"type:text[]"`
 	Keywords      pq.StringArray `gorm:"type:text[]"`
 
-	// JSON fields stored as text
-	CardFaces  *string `gorm:"type:jsonb"`
-	ImageURIs  *string `gorm:"type:jsonb"`
-	Legalities *string `gorm:"type:jsonb"`
-	Prices     *string `gorm:"type:jsonb"`
+	// JSON fields stored as jsonb
+	CardFaces  CardFaces  `gorm:"type:jsonb"`
+	ImageURIs  *ImageURIs `gorm:"type:jsonb"`
+	Legalities Legalities `gorm:"type:jsonb"`
+	Prices     *Prices    `gorm:"type:jsonb"`
 
 	SetCode         string  `gorm:"type:varchar(50);not null"`
 	SetName         *string `gorm:"type:varchar(500)"`
@@ -43,6 +42,8 @@ type Card struct {
 	FlavorText      *string `gorm:"type:text"`
 	ReleasedAt      *string `gorm:"type:varchar(50)"`
 	Lang            string  `gorm:"type:varchar(10);default:''"`
+	// Layout is Scryfall's layout: "normal", "split", "transform", ...
+	Layout          string  `gorm:"type:varchar(50);default:''"`
 	CachedAt        int64   `gorm:"type:bigint;default:0"`
 }
 
@@ -108,6 +109,9 @@ func MapScryfallToCard(data map[string]interface{}) *Card {
 	if lang, ok := data["lang"].(string); ok {
 		card.Lang = lang
 	}
+	if layout, ok := data["layout"].(string); ok {
+		card.Layout = layout
+	}
 
 	// Initialize arrays
 	card.Colors = pq.StringArray{}
@@ -144,30 +148,34 @@ func MapScryfallToCard(data map[string]interface{}) *Card {
 
 	// JSON fields
 	if imageURIs, ok := data["image_uris"].(map[string]interface{}); ok {
-		if jsonBytes, err := json.Marshal(imageURIs); err == nil {
-			jsonStr := string(jsonBytes)
-			card.ImageURIs = &jsonStr
+		var uris ImageURIs
+		if err := decodeJSONField(imageURIs, &uris); err == nil {
+			card.ImageURIs = &uris
 		}
 	}
 
 	if cardFaces, ok := data["card_faces"].([]interface{}); ok {
-		if jsonBytes, err := json.Marshal(cardFaces); err == nil {
-			jsonStr := string(jsonBytes)
-			card.CardFaces = &jsonStr
+		var faces CardFaces
+		if err := decodeJSONField(cardFaces, &faces); err == nil {
+			card.CardFaces = faces
+		}
+		// Double-faced cards have no top-level colors, only per-face ones
+		if len(card.Colors) == 0 {
+			card.Colors = pq.StringArray(faceColors(cardFaces))
 		}
 	}
 
 	if legalities, ok := data["legalities"].(map[string]interface{}); ok {
-		if jsonBytes, err := json.Marshal(legalities); err == nil {
-			jsonStr := string(jsonBytes)
-			card.Legalities = &jsonStr
+		var legal Legalities
+		if err := decodeJSONField(legalities, &legal); err == nil {
+			card.Legalities = legal
 		}
 	}
 
 	if prices, ok := data["prices"].(map[string]interface{}); ok {
-		if jsonBytes, err := json.Marshal(prices); err == nil {
-			jsonStr := string(jsonBytes)
-			card.Prices = &jsonStr
+		var p Prices
+		if err := decodeJSONField(prices, &p); err == nil {
+			card.Prices = &p
 		}
 	}
 
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ImageURIs are the image links Scryfall gives a card or a card face.
+type ImageURIs struct {
+	Small      string `json:"small,omitempty"`
+	Normal     string `json:"normal,omitempty"`
+	Large      string `json:"large,omitempty"`
+	PNG        string `json:"png,omitempty"`
+	ArtCrop    string `json:"art_crop,omitempty"`
+	BorderCrop string `json:"border_crop,omitempty"`
+}
+
+// Format is a Scryfall format key such as "modern" or "commander".
+type Format string
+
+// LegalityStatus is a card's standing in one format.
+type LegalityStatus string
+
+const (
+	Legal      LegalityStatus = "legal"
+	NotLegal   LegalityStatus = "not_legal"
+	Restricted LegalityStatus = "restricted"
+	Banned     LegalityStatus = "banned"
+)
+
+// Formats are the formats Scryfall reports legalities for.
+var Formats = []Format{
+	"standard", "future", "historic", "timeless", "gladiator", "pioneer",
+	"explorer", "modern", "legacy", "pauper", "vintage", "penny",
+	"commander", "oathbreaker", "standardbrawl", "brawl", "alchemy",
+	"paupercommander", "duel", "oldschool", "premodern", "predh",
+}
+
+// ParseFormat checks a format key against Formats, ignoring case.
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Formats {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q", s)
+}
+
+// Playable reports whether a card with this status may be in a deck at
+// all. Restricted cards are, as a single copy.
+func (s LegalityStatus) Playable() bool {
+	return s == Legal || s == Restricted
+}
+
+// Legalities maps each format to the card's status in it.
+type Legalities map[Format]LegalityStatus
+
+// PlayableFormats lists the formats the card may be played in, in the
+// order of Formats.
+func (l Legalities) PlayableFormats() []string {
+	formats := []string{}
+	for _, f := range Formats {
+		if l[f].Playable() {
+			formats = append(formats, string(f))
+		}
+	}
+	return formats
+}
+
+// Prices are the latest Scryfall prices; nil means no price is listed.
+type Prices struct {
+	USD       *Decimal `json:"usd"`
+	USDFoil   *Decimal `json:"usd_foil"`
+	USDEtched *Decimal `json:"usd_etched"`
+	EUR       *Decimal `json:"eur"`
+	EURFoil   *Decimal `json:"eur_foil"`
+	Tix       *Decimal `json:"tix"`
+}
+
+// CardFaces is the list of a multi-faced card's faces.
+type CardFaces []CardFace
+
+// Decimal is a price with two decimal places, kept in hundredths so totals
+// don't pick up floating-point error. It is written as a JSON number and
+// also reads the quoted strings Scryfall sends.
+type Decimal int64
+
+// ParseDecimal parses an amount like "12.5" or "0.03", rounding to cents.
+func ParseDecimal(s string) (Decimal, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal(math.Round(f * 100)), nil
+}
+
+func (d Decimal) String() string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, d/100, d%100)
+}
+
+// Float64 is the amount as a float, for display and ratios.
+func (d Decimal) Float64() float64 {
+	return float64(d) / 100
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseDecimal(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// The types above are stored as jsonb. A nil value is stored as NULL, and
+// NULL scans back as the zero value.
+
+func (i ImageURIs) Value() (driver.Value, error) { return jsonValue(i) }
+func (i *ImageURIs) Scan(src interface{}) error  { return scanJSON(src, i) }
+
+func (l Legalities) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return jsonValue(l)
+}
+func (l *Legalities) Scan(src interface{}) error { return scanJSON(src, l) }
+
+func (p Prices) Value() (driver.Value, error) { return jsonValue(p) }
+func (p *Prices) Scan(src interface{}) error  { return scanJSON(src, p) }
+
+func (f CardFaces) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return jsonValue(f)
+}
+func (f *CardFaces) Scan(src interface{}) error { return scanJSON(src, f) }
+
+func jsonValue(v interface{}) (driver.Value, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func scanJSON(src interface{}, dst interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dst)
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+// decodeJSONField converts a decoded Scryfall value (a map or slice from
+// encoding/json) into one of the typed fields above.
+func decodeJSONField(v interface{}, dst interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Board is the part of a deck an entry belongs to. Commanders get a board
+// of their own so partners and backgrounds need nothing special.
+type Board string
+
+const (
+	BoardMain      Board = "main"
+	BoardSide      Board = "side"
+	BoardMaybe     Board = "maybe"
+	BoardCommander Board = "commander"
+)
+
+// Boards lists every board in display order.
+var Boards = []Board{BoardCommander, BoardMain, BoardSide, BoardMaybe}
+
+// ParseBoard accepts a board name; empty means the main deck.
+func ParseBoard(s string) (Board, error) {
+	if s == "" {
+		return BoardMain, nil
+	}
+	for _, b := range Boards {
+		if string(b) == s {
+			return b, nil
+		}
+	}
+	return "", fmt.Errorf("unknown board %q", s)
+}
+
+// Deck is a saved decklist. Format is empty for decks not built for one.
+type Deck struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Name        string  `gorm:"type:varchar(255);not null" json:"name"`
+	Format      Format  `gorm:"type:varchar(50);default:''" json:"format"`
+	Description *string `gorm:"type:text" json:"description,omitempty"`
+
+	Entries []DeckEntry `json:"entries,omitempty"`
+}
+
+// DeckEntry is a quantity of one card on one board. CardID pins a printing;
+// without one any printing of OracleID will do. OracleID is always set once
+// the entry is saved.
+type DeckEntry struct {
+	ID     uint `gorm:"primaryKey" json:"-"`
+	DeckID uint `gorm:"not null;index" json:"-"`
+
+	Board    Board   `gorm:"type:varchar(20);not null;default:'main'" json:"board"`
+	Quantity int     `gorm:"not null;default:1" json:"quantity"`
+	CardID   *string `gorm:"type:varchar(255)" json:"card_id,omitempty"`
+	OracleID string  `gorm:"type:varchar(255);not null;index" json:"oracle_id"`
+
+	// Card is the pinned printing, or the newest English one, when read
+	Card *Card `gorm:"-" json:"card,omitempty"`
+}
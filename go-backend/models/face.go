@@ -0,0 +1,114 @@
+package models
+
+// CardFace is one face of a multi-faced card: a half of a split or
+// adventure card, or a side of a transform or modal double-faced card.
+// Field names follow Scryfall's card_faces objects so they decode as-is,
+// and every field Scryfall documents is kept so a face stored as jsonb
+// loses nothing.
+type CardFace struct {
+	Object          string     `json:"object,omitempty"`
+	Name            string     `json:"name"`
+	ManaCost        string     `json:"mana_cost"`
+	TypeLine        string     `json:"type_line"`
+	OracleText      string     `json:"oracle_text"`
+	Colors          []string   `json:"colors,omitempty"`
+	ColorIndicator  []string   `json:"color_indicator,omitempty"`
+	CMC             *float64   `json:"cmc,omitempty"`
+	Power           *string    `json:"power,omitempty"`
+	Toughness       *string    `json:"toughness,omitempty"`
+	Loyalty         *string    `json:"loyalty,omitempty"`
+	Defense         *string    `json:"defense,omitempty"`
+	FlavorText      string     `json:"flavor_text,omitempty"`
+	FlavorName      string     `json:"flavor_name,omitempty"`
+	ImageURIs       *ImageURIs `json:"image_uris,omitempty"`
+	Layout          string     `json:"layout,omitempty"`
+	Watermark       string     `json:"watermark,omitempty"`
+	Artist          string     `json:"artist,omitempty"`
+	ArtistID        string     `json:"artist_id,omitempty"`
+	IllustrationID  string     `json:"illustration_id,omitempty"`
+	PrintedName     string     `json:"printed_name,omitempty"`
+	PrintedTypeLine string     `json:"printed_type_line,omitempty"`
+	PrintedText     string     `json:"printed_text,omitempty"`
+	// OracleID is only set on reversible cards, whose faces can be
+	// different cards
+	OracleID string `json:"oracle_id,omitempty"`
+}
+
+// ParsedManaCost parses the face's own mana cost.
+func (f CardFace) ParsedManaCost() (ManaCost, error) {
+	return ParseManaCost(f.ManaCost)
+}
+
+// IsMultiFaced reports whether the card has faces of its own.
+func (c *Card) IsMultiFaced() bool {
+	return len(c.CardFaces) > 0
+}
+
+// Faces returns the card's faces. A single-faced card is returned as one
+// face built from its top-level fields, so callers can treat every card
+// alike.
+func (c *Card) Faces() []CardFace {
+	if !c.IsMultiFaced() {
+		return []CardFace{c.ownFace()}
+	}
+	return c.CardFaces
+}
+
+func (c *Card) ownFace() CardFace {
+	f := CardFace{
+		Name:      c.Name,
+		TypeLine:  c.TypeLine,
+		Colors:    []string(c.Colors),
+		Power:     c.Power,
+		Toughness: c.Toughness,
+		Loyalty:   c.Loyalty,
+		ImageURIs: c.ImageURIs,
+	}
+	if c.ManaCost != nil {
+		f.ManaCost = *c.ManaCost
+	}
+	if c.OracleText != nil {
+		f.OracleText = *c.OracleText
+	}
+	if c.FlavorText != nil {
+		f.FlavorText = *c.FlavorText
+	}
+	return f
+}
+
+// faceColors unions the colours of Scryfall card_faces in WUBRG order.
+// Double-faced cards only list colours per face.
+func faceColors(faces []interface{}) []string {
+	var colors []string
+	for _, f := range faces {
+		face, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		list, _ := face["colors"].([]interface{})
+		for _, c := range list {
+			if color, ok := c.(string); ok {
+				colors = append(colors, color)
+			}
+		}
+	}
+	return sortColors(colors)
+}
+
+// faceView is a face as the API shows it, with its cost broken down.
+type faceView struct {
+	CardFace
+	Mana *ManaInfo `json:"mana,omitempty"`
+}
+
+func faceViews(faces []CardFace) []faceView {
+	views := make([]faceView, len(faces))
+	for i, f := range faces {
+		views[i].CardFace = f
+		if mc, err := f.ParsedManaCost(); err == nil && len(mc.Symbols) > 0 {
+			info := mc.Info()
+			views[i].Mana = &info
+		}
+	}
+	return views
+}
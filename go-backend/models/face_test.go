@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// scryfallFaces is card_faces as Scryfall sends it for a transform card
+// and a reversible one, with every documented field set.
+const scryfallFaces = `[
+  {
+    "object": "card_face",
+    "name": "Delver of Secrets",
+    "mana_cost": "{U}",
+    "type_line": "Creature — Human Wizard",
+    "oracle_text": "At the beginning of your upkeep, look at the top card of your library.",
+    "colors": ["U"],
+    "power": "1",
+    "toughness": "1",
+    "flavor_text": "He scoured the world for knowledge.",
+    "flavor_name": "Curious Apprentice",
+    "artist": "Matt Stewart",
+    "artist_id": "9f8c3e1a-2b4d-4c5e-8f6a-7b8c9d0e1f2a",
+    "illustration_id": "c4e6a0d2-7f1b-4e3a-9c8d-5b6a7f8e9d0c",
+    "watermark": "set",
+    "printed_name": "Entdecker der Geheimnisse",
+    "printed_type_line": "Kreatur — Mensch, Zauberer",
+    "printed_text": "Zu Beginn deines Versorgungssegments …",
+    "image_uris": {"small": "https://cards.example/s.jpg", "normal": "https://cards.example/n.jpg"}
+  },
+  {
+    "object": "card_face",
+    "name": "Insectile Aberration",
+    "mana_cost": "",
+    "type_line": "Creature — Human Insect",
+    "oracle_text": "Flying",
+    "colors": ["U"],
+    "color_indicator": ["U"],
+    "power": "3",
+    "toughness": "2",
+    "artist": "Matt Stewart",
+    "illustration_id": "d5f7b1e3-8a2c-4f4b-0d9e-6c7b8a9f0e1d"
+  },
+  {
+    "object": "card_face",
+    "name": "Zndrsplt, Eye of Wisdom",
+    "mana_cost": "{4}{U}",
+    "type_line": "Legendary Creature — Homunculus",
+    "oracle_text": "Partner with Okaun, Eye of Chaos",
+    "colors": ["U"],
+    "cmc": 5,
+    "layout": "normal",
+    "oracle_id": "2e1a3f4b-5c6d-4e7f-8a9b-0c1d2e3f4a5b",
+    "power": "1",
+    "toughness": "4"
+  }
+]`
+
+// TestCardFacesRoundTrip stores faces the way the cards table does and
+// checks nothing Scryfall sent is lost.
+func TestCardFacesRoundTrip(t *testing.T) {
+	var sent []interface{}
+	if err := json.Unmarshal([]byte(scryfallFaces), &sent); err != nil {
+		t.Fatal(err)
+	}
+	card := MapScryfallToCard(map[string]interface{}{
+		"id": "a0000000-0000-0000-0000-000000000001", "name": "Delver of Secrets // Insectile Aberration",
+		"card_faces": sent,
+	})
+	if len(card.CardFaces) != 3 {
+		t.Fatalf("decoded %d faces, want 3", len(card.CardFaces))
+	}
+
+	stored, err := card.CardFaces.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scanned CardFaces
+	if err := scanned.Scan([]byte(stored.(string))); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(scanned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []interface{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	for i := range sent {
+		if !reflect.DeepEqual(got[i], sent[i]) {
+			t.Errorf("face %d\n got %v\nwant %v", i, got[i], sent[i])
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// GraphSyncState is the fingerprint of what was last sent to Memgraph for
+// an oracle ID, so only cards whose graph data changed are re-sent.
+type GraphSyncState struct {
+	OracleID    string    `gorm:"primaryKey;type:varchar(255)"`
+	Fingerprint string    `gorm:"type:varchar(64);not null"`
+	SyncedAt    time.Time `gorm:"autoUpdateTime"`
+}
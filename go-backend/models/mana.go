@@ -0,0 +1,315 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ColorOrder is the canonical WUBRG order used for colour lists and pips.
+var ColorOrder = []string{"W", "U", "B", "R", "G"}
+
+type SymbolKind string
+
+const (
+	SymbolGeneric   SymbolKind = "generic"   // {2}
+	SymbolColored   SymbolKind = "colored"   // {W}
+	SymbolHybrid    SymbolKind = "hybrid"    // {W/U}, {2/W}, {C/W}
+	SymbolPhyrexian SymbolKind = "phyrexian" // {W/P}, {W/U/P}
+	SymbolSnow      SymbolKind = "snow"      // {S}
+	SymbolX         SymbolKind = "x"         // {X}, {Y}, {Z}
+	SymbolColorless SymbolKind = "colorless" // {C}
+)
+
+// ManaSymbol is one {…} symbol of a mana cost.
+type ManaSymbol struct {
+	Raw    string     `json:"raw"`
+	Kind   SymbolKind `json:"kind"`
+	Colors []string   `json:"colors,omitempty"` // the WUBRG colours that can pay it
+	// Value is what the symbol adds to mana value: {2} is 2, {2/W} is 2,
+	// {X} is 0 and half-mana {HW} is 0.5
+	Value float64 `json:"value"`
+}
+
+// ManaCost is a parsed mana cost such as {2}{W/U}{G/P}.
+type ManaCost struct {
+	Symbols []ManaSymbol `json:"symbols"`
+}
+
+// ParseManaCost parses a Scryfall mana cost. The faces of a split card
+// ("{1}{R} // {2}{U}") are combined into one cost.
+func ParseManaCost(cost string) (ManaCost, error) {
+	mc := ManaCost{Symbols: []ManaSymbol{}}
+	rest := strings.TrimSpace(cost)
+	for rest != "" {
+		if after, ok := strings.CutPrefix(rest, "//"); ok {
+			rest = strings.TrimSpace(after)
+			continue
+		}
+		if rest[0] != '{' {
+			return mc, fmt.Errorf("unexpected %q in mana cost %q", rest, cost)
+		}
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return mc, fmt.Errorf("unterminated symbol in mana cost %q", cost)
+		}
+		sym, err := ParseManaSymbol(rest[:end+1])
+		if err != nil {
+			return mc, fmt.Errorf("mana cost %q: %w", cost, err)
+		}
+		mc.Symbols = append(mc.Symbols, sym)
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+	return mc, nil
+}
+
+// ParseManaSymbol parses a single braced symbol like {G/P}.
+func ParseManaSymbol(raw string) (ManaSymbol, error) {
+	sym := ManaSymbol{Raw: raw}
+	inner := strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(raw, "{"), "}"))
+	if inner == "" || len(inner) == len(raw) {
+		return sym, fmt.Errorf("invalid mana symbol %q", raw)
+	}
+
+	if n, err := strconv.Atoi(inner); err == nil && n >= 0 {
+		sym.Kind, sym.Value = SymbolGeneric, float64(n)
+		return sym, nil
+	}
+	switch inner {
+	case "½":
+		sym.Kind, sym.Value = SymbolGeneric, 0.5
+		return sym, nil
+	case "X", "Y", "Z":
+		sym.Kind = SymbolX
+		return sym, nil
+	case "S":
+		sym.Kind, sym.Value = SymbolSnow, 1
+		return sym, nil
+	case "C":
+		sym.Kind, sym.Value = SymbolColorless, 1
+		return sym, nil
+	}
+	if isColor(inner) {
+		sym.Kind, sym.Value, sym.Colors = SymbolColored, 1, []string{inner}
+		return sym, nil
+	}
+	// Half mana from the Unstable-era sets: {HW}, {HR}
+	if half, ok := strings.CutPrefix(inner, "H"); ok && isColor(half) {
+		sym.Kind, sym.Value, sym.Colors = SymbolColored, 0.5, []string{half}
+		return sym, nil
+	}
+
+	parts := strings.Split(inner, "/")
+	if len(parts) < 2 {
+		return sym, fmt.Errorf("unknown mana symbol %q", raw)
+	}
+	if parts[len(parts)-1] == "P" {
+		sym.Kind = SymbolPhyrexian
+		parts = parts[:len(parts)-1]
+	} else {
+		sym.Kind = SymbolHybrid
+	}
+	sym.Value = 1
+	for _, p := range parts {
+		switch {
+		case isColor(p):
+			sym.Colors = append(sym.Colors, p)
+		case p == "C" && sym.Kind == SymbolHybrid:
+			// {C/W}: payable with colorless mana, adds no colour
+		case sym.Kind == SymbolHybrid && len(parts) == 2 && isGenericAmount(p):
+			// {2/W}: counts as its generic half for mana value
+			n, _ := strconv.Atoi(p)
+			sym.Value = float64(n)
+		default:
+			return sym, fmt.Errorf("unknown mana symbol %q", raw)
+		}
+	}
+	if len(sym.Colors) == 0 {
+		return sym, fmt.Errorf("unknown mana symbol %q", raw)
+	}
+	sym.Colors = sortColors(sym.Colors)
+	return sym, nil
+}
+
+// String formats the cost back into Scryfall notation.
+func (mc ManaCost) String() string {
+	var b strings.Builder
+	for _, s := range mc.Symbols {
+		b.WriteString(s.Raw)
+	}
+	return b.String()
+}
+
+// ManaValue is the total mana value, with X counted as zero.
+func (mc ManaCost) ManaValue() float64 {
+	var total float64
+	for _, s := range mc.Symbols {
+		total += s.Value
+	}
+	return total
+}
+
+// Generic is the amount of generic mana, not counting {X}.
+func (mc ManaCost) Generic() float64 {
+	var total float64
+	for _, s := range mc.Symbols {
+		if s.Kind == SymbolGeneric {
+			total += s.Value
+		}
+	}
+	return total
+}
+
+// HasX reports whether the cost contains {X}, {Y} or {Z}.
+func (mc ManaCost) HasX() bool {
+	for _, s := range mc.Symbols {
+		if s.Kind == SymbolX {
+			return true
+		}
+	}
+	return false
+}
+
+// Pips counts coloured symbols per colour. A hybrid or Phyrexian symbol
+// counts once for each colour it can be paid with.
+func (mc ManaCost) Pips() map[string]int {
+	pips := make(map[string]int)
+	for _, s := range mc.Symbols {
+		for _, c := range s.Colors {
+			pips[c]++
+		}
+	}
+	return pips
+}
+
+// Colors lists the colours appearing in the cost in WUBRG order.
+func (mc ManaCost) Colors() []string {
+	pips := mc.Pips()
+	colors := []string{}
+	for _, c := range ColorOrder {
+		if pips[c] > 0 {
+			colors = append(colors, c)
+		}
+	}
+	return colors
+}
+
+// Devotion counts the symbols that contain any of the given colours, so
+// {W/U} adds one to devotion to white and blue, not two.
+func (mc ManaCost) Devotion(colors ...string) int {
+	n := 0
+	for _, s := range mc.Symbols {
+		for _, c := range s.Colors {
+			if containsColor(colors, c) {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// ManaInfo is the pip breakdown added to a card's API representation.
+type ManaInfo struct {
+	Symbols []ManaSymbol   `json:"symbols"`
+	Pips    map[string]int `json:"pips"`
+	Colors  []string       `json:"colors"`
+	Generic float64        `json:"generic"`
+	HasX    bool           `json:"has_x"`
+	Value   float64        `json:"mana_value"`
+}
+
+func (mc ManaCost) Info() ManaInfo {
+	return ManaInfo{
+		Symbols: mc.Symbols,
+		Pips:    mc.Pips(),
+		Colors:  mc.Colors(),
+		Generic: mc.Generic(),
+		HasX:    mc.HasX(),
+		Value:   mc.ManaValue(),
+	}
+}
+
+// ParsedManaCost parses the card's mana cost. Cards whose cost lives on
+// their faces (transform and modal double-faced cards) use the faces' costs.
+func (c *Card) ParsedManaCost() (ManaCost, error) {
+	if c.ManaCost != nil {
+		return ParseManaCost(*c.ManaCost)
+	}
+	faces := c.Faces()
+	costs := make([]string, 0, len(faces))
+	for _, f := range faces {
+		if f.ManaCost != "" {
+			costs = append(costs, f.ManaCost)
+		}
+	}
+	return ParseManaCost(strings.Join(costs, " // "))
+}
+
+// Pips counts the card's coloured mana symbols per colour. Unparseable
+// costs count as no pips.
+func (c *Card) Pips() map[string]int {
+	mc, err := c.ParsedManaCost()
+	if err != nil {
+		return map[string]int{}
+	}
+	return mc.Pips()
+}
+
+// Devotion is what the card adds to its controller's devotion to colors.
+func (c *Card) Devotion(colors ...string) int {
+	mc, err := c.ParsedManaCost()
+	if err != nil {
+		return 0
+	}
+	return mc.Devotion(colors...)
+}
+
+// MarshalJSON adds the parsed mana cost to the card's usual fields under
+// "Mana", so API clients get pips without parsing ManaCost themselves.
+// Each of a multi-faced card's CardFaces gets its own "mana" the same way.
+func (c Card) MarshalJSON() ([]byte, error) {
+	type plain Card
+	out := struct {
+		plain
+		Mana      *ManaInfo  `json:"Mana,omitempty"`
+		CardFaces []faceView `json:"CardFaces"`
+	}{plain: plain(c)}
+	if mc, err := c.ParsedManaCost(); err == nil && len(mc.Symbols) > 0 {
+		info := mc.Info()
+		out.Mana = &info
+	}
+	if c.IsMultiFaced() {
+		out.CardFaces = faceViews(c.CardFaces)
+	}
+	return json.Marshal(out)
+}
+
+func isColor(s string) bool {
+	return containsColor(ColorOrder, s)
+}
+
+func isGenericAmount(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0
+}
+
+func containsColor(colors []string, c string) bool {
+	for _, x := range colors {
+		if strings.EqualFold(x, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortColors(colors []string) []string {
+	sorted := make([]string, 0, len(colors))
+	for _, c := range ColorOrder {
+		if containsColor(colors, c) {
+			sorted = append(sorted, c)
+		}
+	}
+	return sorted
+}
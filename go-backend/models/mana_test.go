@@ -0,0 +1,200 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseManaSymbol(t *testing.T) {
+	tests := []struct {
+		raw    string
+		kind   SymbolKind
+		colors []string
+		value  float64
+	}{
+		{"{2}", SymbolGeneric, nil, 2},
+		{"{10}", SymbolGeneric, nil, 10},
+		{"{0}", SymbolGeneric, nil, 0},
+		{"{W}", SymbolColored, []string{"W"}, 1},
+		{"{g}", SymbolColored, []string{"G"}, 1},
+		{"{C}", SymbolColorless, nil, 1},
+		{"{S}", SymbolSnow, nil, 1},
+		{"{X}", SymbolX, nil, 0},
+		{"{HW}", SymbolColored, []string{"W"}, 0.5},
+		{"{W/U}", SymbolHybrid, []string{"W", "U"}, 1},
+		{"{U/W}", SymbolHybrid, []string{"W", "U"}, 1},
+		{"{2/W}", SymbolHybrid, []string{"W"}, 2},
+		{"{C/W}", SymbolHybrid, []string{"W"}, 1},
+		{"{G/P}", SymbolPhyrexian, []string{"G"}, 1},
+		{"{W/U/P}", SymbolPhyrexian, []string{"W", "U"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			sym, err := ParseManaSymbol(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sym.Raw != tt.raw || sym.Kind != tt.kind || sym.Value != tt.value ||
+				!reflect.DeepEqual(sym.Colors, tt.colors) {
+				t.Errorf("got %+v, want kind %s colors %v value %v", sym, tt.kind, tt.colors, tt.value)
+			}
+		})
+	}
+}
+
+func TestParseManaSymbolErrors(t *testing.T) {
+	for _, raw := range []string{"{}", "W", "{Q}", "{W/Q}", "{2/3}", "{P}", "{C/P}", "{2/W/U}"} {
+		if sym, err := ParseManaSymbol(raw); err == nil {
+			t.Errorf("%s: got %+v, want an error", raw, sym)
+		}
+	}
+}
+
+func TestParseManaCost(t *testing.T) {
+	tests := []struct {
+		cost     string
+		raws     []string
+		value    float64
+		generic  float64
+		hasX     bool
+		pips     map[string]int
+		colors   []string
+		devotion map[string]int // colour list, joined, to devotion
+	}{
+		{
+			cost: "{2}{W/U}{G/P}", raws: []string{"{2}", "{W/U}", "{G/P}"},
+			value: 4, generic: 2,
+			pips: map[string]int{"W": 1, "U": 1, "G": 1}, colors: []string{"W", "U", "G"},
+			devotion: map[string]int{"W": 1, "WU": 1, "G": 1, "B": 0},
+		},
+		{
+			cost: "{X}{X}{R}", raws: []string{"{X}", "{X}", "{R}"},
+			value: 1, hasX: true,
+			pips: map[string]int{"R": 1}, colors: []string{"R"},
+			devotion: map[string]int{"R": 1},
+		},
+		{
+			cost: "{10}{S}{C}", raws: []string{"{10}", "{S}", "{C}"},
+			value: 12, generic: 10,
+			pips: map[string]int{}, colors: []string{},
+			devotion: map[string]int{"WUBRG": 0},
+		},
+		{
+			cost: "{1}{R} // {2}{U}", raws: []string{"{1}", "{R}", "{2}", "{U}"},
+			value: 5, generic: 3,
+			pips: map[string]int{"R": 1, "U": 1}, colors: []string{"U", "R"},
+			devotion: map[string]int{"U": 1, "UR": 2},
+		},
+		{
+			cost: "{W}{W}{W/B}{HW}", raws: []string{"{W}", "{W}", "{W/B}", "{HW}"},
+			value: 3.5,
+			pips: map[string]int{"W": 4, "B": 1}, colors: []string{"W", "B"},
+			devotion: map[string]int{"W": 4, "B": 1, "WB": 4},
+		},
+		{
+			cost: "", raws: nil,
+			pips: map[string]int{}, colors: []string{},
+			devotion: map[string]int{"W": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cost, func(t *testing.T) {
+			mc, err := ParseManaCost(tt.cost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var raws []string
+			for _, s := range mc.Symbols {
+				raws = append(raws, s.Raw)
+			}
+			if !reflect.DeepEqual(raws, tt.raws) {
+				t.Errorf("symbols = %v, want %v", raws, tt.raws)
+			}
+			if got := mc.ManaValue(); got != tt.value {
+				t.Errorf("ManaValue = %v, want %v", got, tt.value)
+			}
+			if got := mc.Generic(); got != tt.generic {
+				t.Errorf("Generic = %v, want %v", got, tt.generic)
+			}
+			if got := mc.HasX(); got != tt.hasX {
+				t.Errorf("HasX = %v, want %v", got, tt.hasX)
+			}
+			if got := mc.Pips(); !reflect.DeepEqual(got, tt.pips) {
+				t.Errorf("Pips = %v, want %v", got, tt.pips)
+			}
+			if got := mc.Colors(); !reflect.DeepEqual(got, tt.colors) {
+				t.Errorf("Colors = %v, want %v", got, tt.colors)
+			}
+			for colors, want := range tt.devotion {
+				var args []string
+				for _, c := range colors {
+					args = append(args, string(c))
+				}
+				if got := mc.Devotion(args...); got != want {
+					t.Errorf("Devotion(%s) = %d, want %d", colors, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseManaCostErrors(t *testing.T) {
+	for _, cost := range []string{"{}", "{W", "2{W}", "{W}x", "{W} / {U}", "{Q}"} {
+		if mc, err := ParseManaCost(cost); err == nil {
+			t.Errorf("%q: got %v, want an error", cost, mc)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Decimal
+		str  string
+	}{
+		{"12.5", 1250, "12.50"},
+		{"0.03", 3, "0.03"},
+		{" 7 ", 700, "7.00"},
+		{"0.125", 13, "0.13"},
+		{"0.004", 0, "0.00"},
+		{"-2.5", -250, "-2.50"},
+		{"-0.05", -5, "-0.05"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want || got.String() != tt.str {
+			t.Errorf("%q = %d (%s), want %d (%s)", tt.in, got, got, tt.want, tt.str)
+		}
+	}
+	for _, in := range []string{"", "abc", "NaN", "Inf", "1,50"} {
+		if d, err := ParseDecimal(in); err == nil {
+			t.Errorf("%q: got %s, want an error", in, d)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var prices Prices
+	err := json.Unmarshal([]byte(`{"usd": "1.99", "eur": 0.5, "tix": null}`), &prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prices.USD == nil || *prices.USD != 199 || prices.EUR == nil || *prices.EUR != 50 || prices.Tix != nil {
+		t.Fatalf("got %+v", prices)
+	}
+	out, err := json.Marshal(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"usd":1.99,"usd_foil":null,"usd_etched":null,"eur":0.50,"eur_foil":null,"tix":null}`; string(out) != want {
+		t.Errorf("marshal = %s, want %s", out, want)
+	}
+	if err := json.Unmarshal([]byte(`{"usd": "n/a"}`), &prices); err == nil {
+		t.Error("bad price decoded without an error")
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// DataMigration records a one-off data migration that has been applied, so
+// it isn't run again on the next start.
+type DataMigration struct {
+	Name      string    `gorm:"primaryKey;type:varchar(255)"`
+	AppliedAt time.Time `gorm:"autoCreateTime"`
+}
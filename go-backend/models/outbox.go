@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	OutboxUpsert = "upsert"
+	OutboxDelete = "delete"
+
+	OutboxPending = "pending"
+	OutboxDead    = "dead"
+)
+
+// OutboxEvent says an oracle ID changed in Postgres and the graph needs to
+// catch up. Events are written in the same transaction as the card change
+// and deleted once the relay has applied them to Memgraph.
+type OutboxEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	OracleID      string    `gorm:"type:varchar(255);not null" json:"oracle_id"`
+	Op            string    `gorm:"type:varchar(20);not null" json:"op"`
+	Status        string    `gorm:"type:varchar(20);not null;default:'pending';index:idx_outbox_ready,priority:1" json:"status"`
+	Attempts      int       `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_outbox_ready,priority:2" json:"next_attempt_at"`
+	LastError     string    `gorm:"type:text;default:''" json:"last_error"`
+}
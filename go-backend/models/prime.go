@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PrimeCheckpoint records how far a prime run got through a bulk file so
+// an interrupted run can pick up where it stopped instead of starting over.
+type PrimeCheckpoint struct {
+	Source    string    `gorm:"primaryKey;type:varchar(1000)"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	// SourceSize is the size of the file when the run started, -1 if unknown.
+	// A different size means the file was replaced and the checkpoint is stale.
+	SourceSize  int64  `gorm:"type:bigint"`
+	ByteOffset  int64  `gorm:"type:bigint;default:0"`
+	CardCount   int64  `gorm:"type:bigint;default:0"`
+	LastCardID  string `gorm:"type:varchar(255);default:''"`
+	CompletedAt *time.Time
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+)
+
+// Ruling is an entry from Scryfall's rulings bulk file. Rulings have no ID of
+// their own, so ID is a hash of the fields that make a ruling unique.
+type Ruling struct {
+	ID        string    `gorm:"primaryKey;type:varchar(40)"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	OracleID    string  `gorm:"type:varchar(255);not null;index"`
+	Source      string  `gorm:"type:varchar(50);not null"`
+	PublishedAt *string `gorm:"type:varchar(50)"`
+	Comment     string  `gorm:"type:text;not null"`
+}
+
+// MapScryfallToRuling converts Scryfall ruling JSON to a Ruling model
+func MapScryfallToRuling(data map[string]interface{}) *Ruling {
+	ruling := &Ruling{}
+
+	if oracleID, ok := data["oracle_id"].(string); ok {
+		ruling.OracleID = oracleID
+	}
+	if source, ok := data["source"].(string); ok {
+		ruling.Source = source
+	}
+	if publishedAt, ok := data["published_at"].(string); ok {
+		ruling.PublishedAt = &publishedAt
+	}
+	if comment, ok := data["comment"].(string); ok {
+		ruling.Comment = comment
+	}
+
+	published := ""
+	if ruling.PublishedAt != nil {
+		published = *ruling.PublishedAt
+	}
+	sum := sha1.Sum([]byte(ruling.OracleID + "\x00" + ruling.Source + "\x00" + published + "\x00" + ruling.Comment))
+	ruling.ID = hex.EncodeToString(sum[:])
+
+	return ruling
+}
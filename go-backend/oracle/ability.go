@@ -0,0 +1,74 @@
+// Package oracle parses a card's oracle text into structured abilities.
+package oracle
+
+// Kind says what sort of ability a line of oracle text is.
+type Kind string
+
+const (
+	KindKeyword   Kind = "keyword"   // "Flying, trample", "Equip {2}"
+	KindStatic    Kind = "static"    // always-on text on a permanent
+	KindActivated Kind = "activated" // "cost: effect", including loyalty abilities
+	KindTriggered Kind = "triggered" // "when/whenever/at ..., effect"
+	KindSpell     Kind = "spell"     // what an instant or sorcery does on resolution
+)
+
+// CostKind classifies one comma-separated part of an activation cost.
+type CostKind string
+
+const (
+	CostMana      CostKind = "mana"      // {2}{G}
+	CostTap       CostKind = "tap"       // {T}, or tapping other permanents
+	CostUntap     CostKind = "untap"     // {Q}
+	CostLoyalty   CostKind = "loyalty"   // +1, −2, 0, −X
+	CostSacrifice CostKind = "sacrifice" // Sacrifice a creature
+	CostDiscard   CostKind = "discard"   // Discard a card
+	CostLife      CostKind = "life"      // Pay 2 life
+	CostExile     CostKind = "exile"     // Exile a card from your graveyard
+	CostCounters  CostKind = "counters"  // Remove a +1/+1 counter from CARDNAME
+	CostReturn    CostKind = "return"    // Return a land you control to its owner's hand
+	CostEnergy    CostKind = "energy"    // Pay {E}{E}
+	CostOther     CostKind = "other"
+)
+
+// Ability is one paragraph of oracle text.
+type Ability struct {
+	Kind Kind   `json:"kind"`
+	Text string `json:"text"`
+	// AbilityWord is a flavour label such as "Landfall" in "Landfall — ..."
+	AbilityWord string     `json:"ability_word,omitempty"`
+	Keywords    []string   `json:"keywords,omitempty"`
+	Cost        []CostPart `json:"cost,omitempty"`
+	Trigger     *Trigger   `json:"trigger,omitempty"`
+	// Effect is the text after the cost or trigger condition
+	Effect  string   `json:"effect,omitempty"`
+	Effects []string `json:"effects,omitempty"`
+	Modal   *Modal   `json:"modal,omitempty"`
+}
+
+// CostPart is one element of an activation cost.
+type CostPart struct {
+	Kind CostKind `json:"kind"`
+	Text string   `json:"text"`
+	// Symbols holds the {…} symbols of mana and energy costs
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// Trigger is the condition of a triggered ability.
+type Trigger struct {
+	Word      string   `json:"word"` // when, whenever or at
+	Condition string   `json:"condition"`
+	Events    []string `json:"events,omitempty"`
+}
+
+// Modal is a "Choose one —" style list of modes.
+type Modal struct {
+	Choose string `json:"choose"` // "one", "one or more", "two", ...
+	Modes  []Mode `json:"modes"`
+}
+
+// Mode is one bullet of a modal ability. Spree modes carry an extra cost.
+type Mode struct {
+	Text    string     `json:"text"`
+	Cost    []CostPart `json:"cost,omitempty"`
+	Effects []string   `json:"effects,omitempty"`
+}
@@ -0,0 +1,83 @@
+package oracle
+
+import "regexp"
+
+type pattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+func patterns(pairs ...string) []pattern {
+	out := make([]pattern, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out = append(out, pattern{name: pairs[i], re: regexp.MustCompile("(?i)" + pairs[i+1])})
+	}
+	return out
+}
+
+// effectVerbs are the actions an effect performs, in a stable order
+var effectVerbs = patterns(
+	"destroy", `\bdestroys?\b`,
+	"exile", `\bexiles?\b`,
+	"draw", `\bdraws? (?:a|an|one|two|three|four|five|seven|x|that many|\d+|cards)\b`,
+	"discard", `\bdiscards?\b`,
+	"create_token", `\bcreates?\b[^.]*\btokens?\b`,
+	"counter", `\bcounter (?:target|it|that|all|up to)\b`,
+	"return", `\breturns?\b`,
+	"search", `\bsearch(?:es)?\b[^.]*\blibrary\b`,
+	"gain_life", `\bgains? (?:\d+|x|that much|life)\b[^.]*?\blife\b|\bgains? life\b`,
+	"lose_life", `\bloses? (?:\d+|x|that much|life)\b[^.]*?\blife\b|\bloses? life\b`,
+	"damage", `\bdeals? [^.]*\bdamage\b`,
+	"sacrifice", `\bsacrifices?\b`,
+	"tap", `\btaps?\b`,
+	"untap", `\buntaps?\b`,
+	"add_mana", `\badd (?:\{|one mana|two mana|three mana|x mana|an amount of|mana)`,
+	"put_counters", `\bputs? [^.]*\bcounters? on\b`,
+	"scry", `\bscry\b`,
+	"surveil", `\bsurveil\b`,
+	"mill", `\bmills?\b`,
+	"copy", `\bcop(?:y|ies)\b`,
+	"gain_control", `\bgains? control\b`,
+	"modify_pt", `\bgets? [+\-−](?:\d+|x)/[+\-−](?:\d+|x)\b`,
+	"fight", `\bfights?\b`,
+)
+
+// triggerEvents are the game events a trigger condition waits for
+var triggerEvents = patterns(
+	"enters", `\benters\b`,
+	"landfall", `\ba land\b[^,]*\benters\b`,
+	"dies", `\bdies\b|\bput into a graveyard from the battlefield\b`,
+	"leaves", `\bleaves the battlefield\b`,
+	"attacks", `\battacks?\b`,
+	"blocks", `\bblocks?\b|\bbecomes blocked\b`,
+	"cast", `\bcasts?\b`,
+	"upkeep", `\bupkeep\b`,
+	"draw_step", `\bdraw step\b`,
+	"combat", `\bbeginning of combat\b`,
+	"end_step", `\bend step\b`,
+	"draw", `\bdraws?\b`,
+	"damage", `\bdeals? (?:combat )?damage\b|\bis dealt damage\b`,
+	"life_gain", `\bgains? life\b`,
+	"sacrifice", `\bsacrifices?\b`,
+	"discard", `\bdiscards?\b`,
+	"targeted", `\bbecomes the target\b`,
+	"counters", `\bcounters? (?:is|are) put\b`,
+	"tapped", `\bbecomes tapped\b|\bis tapped\b`,
+)
+
+func classifyEffects(text string) []string { return match(effectVerbs, text) }
+
+func classifyEvents(text string) []string { return match(triggerEvents, text) }
+
+func match(ps []pattern, text string) []string {
+	if text == "" {
+		return nil
+	}
+	var names []string
+	for _, p := range ps {
+		if p.re.MatchString(text) {
+			names = append(names, p.name)
+		}
+	}
+	return names
+}
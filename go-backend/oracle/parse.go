@@ -0,0 +1,274 @@
+package oracle
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	abilityWord = regexp.MustCompile(`^([A-Z][\w' -]*?) — (.+)$`)
+	sagaChapter = regexp.MustCompile(`^((?:I|II|III|IV|V|VI)(?:, (?:I|II|III|IV|V|VI))*) — (.+)$`)
+	spreeMode   = regexp.MustCompile(`^\+ ((?:\{[^}]+\})+) — (.+)$`)
+	modalHeader = regexp.MustCompile(`(?i)\bchoose (one or more|one or both|one|two|three|four|five|any number|up to (?:one|two|three|four|five))\b[^.]*—$`)
+	loyaltyCost = regexp.MustCompile(`^[+−-]?(?:\d+|X)$`)
+	symbolRun   = regexp.MustCompile(`^(?:\{[^}]+\})+$`)
+	symbol      = regexp.MustCompile(`\{[^}]+\}`)
+)
+
+// Parse splits oracle text into abilities, one per paragraph, with modal
+// bullets attached to the paragraph that introduces them. Reminder text is
+// dropped and the card's name is replaced with SelfName. typeLine decides
+// whether plain sentences are spell effects or static abilities.
+func Parse(name, typeLine, oracleText string) []Ability {
+	text := ReplaceSelfName(name, StripReminderText(oracleText))
+	spell := strings.Contains(typeLine, "Instant") || strings.Contains(typeLine, "Sorcery")
+
+	abilities := []Ability{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if mode, ok := parseMode(line); ok {
+			if len(abilities) == 0 {
+				// A bullet with nothing to hang off; keep it rather than lose it
+				abilities = append(abilities, Ability{Kind: KindStatic, Text: line, Modal: &Modal{}})
+			}
+			last := &abilities[len(abilities)-1]
+			if last.Modal == nil {
+				last.Modal = &Modal{}
+				if slices.Contains(last.Keywords, "Spree") {
+					last.Modal.Choose = "one or more"
+				}
+			}
+			last.Modal.Modes = append(last.Modal.Modes, mode)
+			continue
+		}
+
+		abilities = append(abilities, parseLine(line, spell))
+	}
+	return abilities
+}
+
+func parseLine(line string, spell bool) Ability {
+	a := Ability{Text: line}
+
+	if m := sagaChapter.FindStringSubmatch(line); m != nil {
+		a.Kind = KindTriggered
+		a.Trigger = &Trigger{Word: "chapter", Condition: m[1], Events: []string{"chapter"}}
+		a.Effect = m[2]
+		a.Effects = classifyEffects(a.Effect)
+		return a
+	}
+
+	body := line
+	if m := abilityWord.FindStringSubmatch(line); m != nil && !strings.HasPrefix(m[1], "Choose") {
+		a.AbilityWord = m[1]
+		body = m[2]
+	}
+
+	if word := triggerWord(body); word != "" {
+		rest := strings.TrimSpace(body[len(word):])
+		condition, effect := splitTrigger(rest)
+		a.Kind = KindTriggered
+		a.Trigger = &Trigger{
+			Word:      strings.ToLower(word),
+			Condition: condition,
+			Events:    classifyEvents(condition),
+		}
+		a.Effect = effect
+	} else if i := costEnd(body); i >= 0 {
+		a.Kind = KindActivated
+		a.Cost = parseCost(body[:i])
+		a.Effect = strings.TrimSpace(body[i+1:])
+	} else if isKeywordLine(body) {
+		a.Kind = KindKeyword
+		a.Keywords = splitKeywords(body)
+		return a
+	} else if spell {
+		a.Kind = KindSpell
+		a.Effect = body
+	} else {
+		a.Kind = KindStatic
+		a.Effect = body
+	}
+
+	a.Effects = classifyEffects(a.Effect)
+	if m := modalHeader.FindStringSubmatch(a.Effect); m != nil {
+		a.Modal = &Modal{Choose: strings.ToLower(m[1])}
+	}
+	return a
+}
+
+// splitTrigger cuts a trigger at the comma that ends its condition. Commas
+// of a list inside the condition ("Whenever a creature, planeswalker, or
+// battle enters, ...") are skipped: a run of short items closed by an
+// "or"/"and" item that more text follows. A list closed at the end of the
+// sentence belongs to the effect instead ("When CARDNAME enters, destroy
+// target artifact, enchantment, or land.").
+func splitTrigger(rest string) (condition, effect string) {
+	segments := strings.Split(rest, ", ")
+	end := 1
+	for end < len(segments) {
+		closing := -1
+		for i := end; i < len(segments)-1; i++ {
+			if listCloser(segments[i]) {
+				closing = i
+				break
+			}
+			if len(strings.Fields(segments[i])) > maxListItemWords {
+				break
+			}
+		}
+		if closing < 0 {
+			break
+		}
+		end = closing + 1
+	}
+	return strings.Join(segments[:end], ", "), strings.Join(segments[end:], ", ")
+}
+
+// maxListItemWords is the longest item splitTrigger treats as part of a list
+const maxListItemWords = 3
+
+func listCloser(segment string) bool {
+	for _, w := range []string{"or ", "and ", "and/or "} {
+		if strings.HasPrefix(segment, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMode recognises a "• effect" bullet or a "+ {1} — effect" spree mode
+func parseMode(line string) (Mode, bool) {
+	if rest, ok := strings.CutPrefix(line, "•"); ok {
+		text := strings.TrimSpace(rest)
+		return Mode{Text: text, Effects: classifyEffects(text)}, true
+	}
+	if m := spreeMode.FindStringSubmatch(line); m != nil {
+		return Mode{Text: m[2], Cost: parseCost(m[1]), Effects: classifyEffects(m[2])}, true
+	}
+	return Mode{}, false
+}
+
+func triggerWord(body string) string {
+	for _, w := range []string{"Whenever", "When", "At"} {
+		if strings.HasPrefix(body, w+" ") {
+			return w
+		}
+	}
+	return ""
+}
+
+// costEnd returns the index of the colon ending an activation cost, or -1.
+// Colons inside quoted abilities ("Enchanted land has "{T}: Add {G}."")
+// don't count, and a cost never spans a sentence.
+func costEnd(body string) int {
+	i := strings.Index(body, ":")
+	if i <= 0 {
+		return -1
+	}
+	prefix := body[:i]
+	if strings.ContainsAny(prefix, `."“”`) {
+		return -1
+	}
+	return i
+}
+
+// isKeywordLine reports whether a line is a bare keyword list. Keyword
+// lines never end in a full stop; rules text always does.
+func isKeywordLine(body string) bool {
+	if strings.HasSuffix(body, ".") || strings.HasSuffix(body, "—") || strings.Contains(body, `"`) {
+		return false
+	}
+	first := body[0]
+	return first >= 'A' && first <= 'Z'
+}
+
+func splitKeywords(body string) []string {
+	var keywords []string
+	for _, part := range strings.FieldsFunc(body, func(r rune) bool { return r == ',' || r == ';' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			keywords = append(keywords, part)
+		}
+	}
+	return keywords
+}
+
+func parseCost(cost string) []CostPart {
+	// Every cost part starts with a capital, a symbol or a loyalty sign, so a
+	// lowercase piece is a list inside the previous part ("Sacrifice an
+	// artifact, creature, or land")
+	var texts []string
+	for _, raw := range strings.Split(cost, ",") {
+		text := strings.TrimSpace(raw)
+		if text == "" {
+			continue
+		}
+		if len(texts) > 0 && text[0] >= 'a' && text[0] <= 'z' {
+			texts[len(texts)-1] += ", " + text
+			continue
+		}
+		texts = append(texts, text)
+	}
+
+	parts := make([]CostPart, len(texts))
+	for i, text := range texts {
+		parts[i] = classifyCost(text)
+	}
+	return parts
+}
+
+func classifyCost(text string) CostPart {
+	part := CostPart{Kind: CostOther, Text: text}
+	lower := strings.ToLower(text)
+
+	switch {
+	case symbolRun.MatchString(text):
+		part.Symbols = symbol.FindAllString(text, -1)
+		switch {
+		case allSymbols(part.Symbols, "{T}"):
+			part.Kind, part.Symbols = CostTap, nil
+		case allSymbols(part.Symbols, "{Q}"):
+			part.Kind, part.Symbols = CostUntap, nil
+		case allSymbols(part.Symbols, "{E}"):
+			part.Kind = CostEnergy
+		default:
+			part.Kind = CostMana
+		}
+	case loyaltyCost.MatchString(text):
+		part.Kind = CostLoyalty
+	case strings.HasPrefix(lower, "sacrifice"):
+		part.Kind = CostSacrifice
+	case strings.HasPrefix(lower, "discard"):
+		part.Kind = CostDiscard
+	case strings.HasPrefix(lower, "pay ") && strings.Contains(lower, "life"):
+		part.Kind = CostLife
+	case strings.HasPrefix(lower, "pay {e}"):
+		part.Kind = CostEnergy
+		part.Symbols = symbol.FindAllString(text, -1)
+	case strings.HasPrefix(lower, "exile"):
+		part.Kind = CostExile
+	case strings.HasPrefix(lower, "remove"):
+		part.Kind = CostCounters
+	case strings.HasPrefix(lower, "tap "):
+		part.Kind = CostTap
+	case strings.HasPrefix(lower, "untap "):
+		part.Kind = CostUntap
+	case strings.HasPrefix(lower, "return"):
+		part.Kind = CostReturn
+	}
+	return part
+}
+
+func allSymbols(symbols []string, want string) bool {
+	for _, s := range symbols {
+		if !strings.EqualFold(s, want) {
+			return false
+		}
+	}
+	return len(symbols) > 0
+}
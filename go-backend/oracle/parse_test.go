@@ -0,0 +1,279 @@
+package oracle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTriggers(t *testing.T) {
+	for _, tc := range []struct {
+		name, text  string
+		abilityWord string
+		trigger     Trigger
+		effect      string
+	}{
+		{
+			name:    "Meteor Golem",
+			text:    "When Meteor Golem enters, destroy target nonland permanent an opponent controls.",
+			trigger: Trigger{Word: "when", Condition: "CARDNAME enters", Events: []string{"enters"}},
+			effect:  "destroy target nonland permanent an opponent controls.",
+		},
+		{
+			name:        "Omnath, Locus of Creation",
+			text:        "Landfall — Whenever a land you control enters, you gain 4 life.",
+			abilityWord: "Landfall",
+			trigger:     Trigger{Word: "whenever", Condition: "a land you control enters", Events: []string{"enters", "landfall"}},
+			effect:      "you gain 4 life.",
+		},
+		{
+			name:    "Phyrexian Arena",
+			text:    "At the beginning of your upkeep, you draw a card and you lose 1 life.",
+			trigger: Trigger{Word: "at", Condition: "the beginning of your upkeep", Events: []string{"upkeep"}},
+			effect:  "you draw a card and you lose 1 life.",
+		},
+		{
+			// The list in the condition keeps its commas
+			name:    "Rite of Harmony",
+			text:    "Whenever a creature, planeswalker, or battle you control dies, draw a card.",
+			trigger: Trigger{Word: "whenever", Condition: "a creature, planeswalker, or battle you control dies", Events: []string{"dies"}},
+			effect:  "draw a card.",
+		},
+		{
+			// A list ending the sentence is the effect's
+			name:    "Aura Shards Golem",
+			text:    "When Aura Shards Golem enters, destroy target artifact, enchantment, or land.",
+			trigger: Trigger{Word: "when", Condition: "CARDNAME enters", Events: []string{"enters"}},
+			effect:  "destroy target artifact, enchantment, or land.",
+		},
+		{
+			name:    "Young Pyromancer",
+			text:    "Whenever you cast an instant or sorcery spell, create a 1/1 red Elemental creature token.",
+			trigger: Trigger{Word: "whenever", Condition: "you cast an instant or sorcery spell", Events: []string{"cast"}},
+			effect:  "create a 1/1 red Elemental creature token.",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			abilities := Parse(tc.name, "Creature", tc.text)
+			if len(abilities) != 1 {
+				t.Fatalf("got %d abilities, want 1: %+v", len(abilities), abilities)
+			}
+			a := abilities[0]
+			if a.Kind != KindTriggered {
+				t.Errorf("kind = %s, want %s", a.Kind, KindTriggered)
+			}
+			if a.AbilityWord != tc.abilityWord {
+				t.Errorf("ability word = %q, want %q", a.AbilityWord, tc.abilityWord)
+			}
+			if a.Trigger == nil || !reflect.DeepEqual(*a.Trigger, tc.trigger) {
+				t.Errorf("trigger = %+v, want %+v", a.Trigger, tc.trigger)
+			}
+			if a.Effect != tc.effect {
+				t.Errorf("effect = %q, want %q", a.Effect, tc.effect)
+			}
+		})
+	}
+}
+
+func TestParseModal(t *testing.T) {
+	for _, tc := range []struct {
+		name, typeLine, text string
+		want                 Modal
+	}{
+		{
+			name:     "Abzan Charm",
+			typeLine: "Instant",
+			text: "Choose one —\n• Exile target creature with power 3 or greater.\n" +
+				"• You draw two cards and you lose 2 life.\n" +
+				"• Distribute two +1/+1 counters among one or two target creatures.",
+			want: Modal{Choose: "one", Modes: []Mode{
+				{Text: "Exile target creature with power 3 or greater.", Effects: []string{"exile"}},
+				{Text: "You draw two cards and you lose 2 life.", Effects: []string{"draw", "lose_life"}},
+				{Text: "Distribute two +1/+1 counters among one or two target creatures."},
+			}},
+		},
+		{
+			name:     "Fell the Profane",
+			typeLine: "Sorcery",
+			text:     "Choose one or both —\n• Destroy target creature.\n• Draw a card.",
+			want: Modal{Choose: "one or both", Modes: []Mode{
+				{Text: "Destroy target creature.", Effects: []string{"destroy"}},
+				{Text: "Draw a card.", Effects: []string{"draw"}},
+			}},
+		},
+		{
+			// Spree modes each carry their own cost
+			name:     "Great Train Heist",
+			typeLine: "Instant",
+			text: "Spree (Choose one or more additional costs.)\n" +
+				"+ {2} — Untap all creatures you control.\n" +
+				"+ {R} — Creatures you control get +1/+0 until end of turn.",
+			want: Modal{Choose: "one or more", Modes: []Mode{
+				{
+					Text:    "Untap all creatures you control.",
+					Cost:    []CostPart{{Kind: CostMana, Text: "{2}", Symbols: []string{"{2}"}}},
+					Effects: []string{"untap"},
+				},
+				{
+					Text:    "Creatures you control get +1/+0 until end of turn.",
+					Cost:    []CostPart{{Kind: CostMana, Text: "{R}", Symbols: []string{"{R}"}}},
+					Effects: []string{"modify_pt"},
+				},
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			abilities := Parse(tc.name, tc.typeLine, tc.text)
+			if len(abilities) != 1 {
+				t.Fatalf("got %d abilities, want 1: %+v", len(abilities), abilities)
+			}
+			if got := abilities[0].Modal; got == nil || !reflect.DeepEqual(*got, tc.want) {
+				t.Errorf("modal = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSaga(t *testing.T) {
+	text := "(As this Saga enters and after your draw step, add a lore counter. Sacrifice after III.)\n" +
+		"I — Each opponent sacrifices a creature or planeswalker.\n" +
+		"II — Each opponent discards a card.\n" +
+		"III — Put target creature or planeswalker card from a graveyard onto the battlefield under your control."
+	abilities := Parse("The Eldest Reborn", "Enchantment — Saga", text)
+
+	want := []struct{ chapter, effect string }{
+		{"I", "Each opponent sacrifices a creature or planeswalker."},
+		{"II", "Each opponent discards a card."},
+		{"III", "Put target creature or planeswalker card from a graveyard onto the battlefield under your control."},
+	}
+	if len(abilities) != len(want) {
+		t.Fatalf("got %d abilities, want %d: %+v", len(abilities), len(want), abilities)
+	}
+	for i, w := range want {
+		a := abilities[i]
+		if a.Kind != KindTriggered || a.Trigger == nil || a.Trigger.Word != "chapter" || a.Trigger.Condition != w.chapter {
+			t.Errorf("chapter %s: got %+v", w.chapter, a)
+		}
+		if a.Effect != w.effect {
+			t.Errorf("chapter %s: effect = %q, want %q", w.chapter, a.Effect, w.effect)
+		}
+	}
+}
+
+func TestParseCosts(t *testing.T) {
+	for _, tc := range []struct {
+		name, typeLine, text string
+		cost                 []CostPart
+		effect               string
+	}{
+		{
+			name:     "Llanowar Elves",
+			typeLine: "Creature — Elf Druid",
+			text:     "{T}: Add {G}.",
+			cost:     []CostPart{{Kind: CostTap, Text: "{T}"}},
+			effect:   "Add {G}.",
+		},
+		{
+			// The sacrificed list stays one cost part
+			name:     "Ashnod's Altar",
+			typeLine: "Artifact",
+			text:     "{2}{B}, {T}, Sacrifice an artifact, creature, or land: Draw a card.",
+			cost: []CostPart{
+				{Kind: CostMana, Text: "{2}{B}", Symbols: []string{"{2}", "{B}"}},
+				{Kind: CostTap, Text: "{T}"},
+				{Kind: CostSacrifice, Text: "Sacrifice an artifact, creature, or land"},
+			},
+			effect: "Draw a card.",
+		},
+		{
+			name:     "Liliana of the Veil",
+			typeLine: "Legendary Planeswalker — Liliana",
+			text:     "−2: Target player sacrifices a creature.",
+			cost:     []CostPart{{Kind: CostLoyalty, Text: "−2"}},
+			effect:   "Target player sacrifices a creature.",
+		},
+		{
+			name:     "Greater Gargadon",
+			typeLine: "Creature — Beast",
+			text:     "Sacrifice an artifact, creature, or land: Remove a time counter from Greater Gargadon.",
+			cost:     []CostPart{{Kind: CostSacrifice, Text: "Sacrifice an artifact, creature, or land"}},
+			effect:   "Remove a time counter from CARDNAME.",
+		},
+		{
+			name:     "Bloodstained Mire",
+			typeLine: "Land",
+			text:     "{T}, Pay 1 life, Sacrifice Bloodstained Mire: Search your library for a Swamp or Mountain card, put it onto the battlefield, then shuffle.",
+			cost: []CostPart{
+				{Kind: CostTap, Text: "{T}"},
+				{Kind: CostLife, Text: "Pay 1 life"},
+				{Kind: CostSacrifice, Text: "Sacrifice CARDNAME"},
+			},
+			effect: "Search your library for a Swamp or Mountain card, put it onto the battlefield, then shuffle.",
+		},
+		{
+			name:     "Aether Hub",
+			typeLine: "Land",
+			text:     "{T}, Pay {E}: Add one mana of any color.",
+			cost: []CostPart{
+				{Kind: CostTap, Text: "{T}"},
+				{Kind: CostEnergy, Text: "Pay {E}", Symbols: []string{"{E}"}},
+			},
+			effect: "Add one mana of any color.",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			abilities := Parse(tc.name, tc.typeLine, tc.text)
+			if len(abilities) != 1 {
+				t.Fatalf("got %d abilities, want 1: %+v", len(abilities), abilities)
+			}
+			a := abilities[0]
+			if a.Kind != KindActivated {
+				t.Errorf("kind = %s, want %s", a.Kind, KindActivated)
+			}
+			if !reflect.DeepEqual(a.Cost, tc.cost) {
+				t.Errorf("cost = %+v, want %+v", a.Cost, tc.cost)
+			}
+			if a.Effect != tc.effect {
+				t.Errorf("effect = %q, want %q", a.Effect, tc.effect)
+			}
+		})
+	}
+}
+
+func TestParseKinds(t *testing.T) {
+	for _, tc := range []struct {
+		name, typeLine, text string
+		want                 []Kind
+	}{
+		{"Serra Angel", "Creature — Angel", "Flying, vigilance", []Kind{KindKeyword}},
+		{"Lightning Bolt", "Instant", "Lightning Bolt deals 3 damage to any target.", []Kind{KindSpell}},
+		{"Glorious Anthem", "Enchantment", "Creatures you control get +1/+1.", []Kind{KindStatic}},
+		{
+			// A colon inside a granted ability isn't a cost
+			"Utopia Sprawl", "Enchantment — Aura",
+			"Enchant Forest\nEnchanted land has \"{T}: Add {G}.\"",
+			[]Kind{KindKeyword, KindStatic},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []Kind
+			for _, a := range Parse(tc.name, tc.typeLine, tc.text) {
+				got = append(got, a.Kind)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("kinds = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReplaceSelfName(t *testing.T) {
+	for _, tc := range []struct{ name, text, want string }{
+		{"Lightning Bolt", "Lightning Bolt deals 3 damage.", "CARDNAME deals 3 damage."},
+		{"Ayara, First of Locthwain", "Whenever Ayara or another black creature enters", "Whenever CARDNAME or another black creature enters"},
+		{"Fire // Ice", "Ice deals nothing; Fire deals 2 damage.", "CARDNAME deals nothing; CARDNAME deals 2 damage."},
+	} {
+		if got := ReplaceSelfName(tc.name, tc.text); got != tc.want {
+			t.Errorf("ReplaceSelfName(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
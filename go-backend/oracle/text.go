@@ -0,0 +1,41 @@
+package oracle
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SelfName replaces the card's own name in parsed text, so abilities of
+// different cards compare equal ("When CARDNAME enters, ...").
+const SelfName = "CARDNAME"
+
+var reminderText = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// StripReminderText drops parenthesised reminder text.
+func StripReminderText(text string) string {
+	return reminderText.ReplaceAllString(text, "")
+}
+
+// ReplaceSelfName swaps the card's name for SelfName, including a legendary
+// short name ("Ayara" for "Ayara, First of Locthwain") and each face of an
+// "A // B" card.
+func ReplaceSelfName(name, text string) string {
+	var names []string
+	for _, face := range strings.Split(name, " // ") {
+		face = strings.TrimSpace(face)
+		if face == "" {
+			continue
+		}
+		names = append(names, face)
+		if short, _, ok := strings.Cut(face, ", "); ok && len(short) > 2 {
+			names = append(names, short)
+		}
+	}
+	// Longest first so a short name never eats part of the full one
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, n := range names {
+		text = strings.ReplaceAll(text, n, SelfName)
+	}
+	return text
+}
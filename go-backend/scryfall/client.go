@@ -0,0 +1,97 @@
+package scryfall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// BulkData is one entry of the /bulk-data manifest.
+type BulkData struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	Name            string    `json:"name"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	URI             string    `json:"uri"`
+	DownloadURI     string    `json:"download_uri"`
+	Size            int64     `json:"size"`
+	ContentType     string    `json:"content_type"`
+	ContentEncoding string    `json:"content_encoding"`
+}
+
+// BulkSource is where bulk files come from. Client talks to Scryfall (or
+// anything serving the same shapes); tests can supply their own.
+type BulkSource interface {
+	BulkManifest(ctx context.Context) ([]BulkData, error)
+	Download(ctx context.Context, uri string) (io.ReadCloser, error)
+}
+
+// Client fetches bulk-data manifests and files over HTTP.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		// Bulk files are large, so no overall timeout; callers cancel via ctx
+		HTTP: &http.Client{},
+	}
+}
+
+// BulkManifest returns every bulk file listed at {BaseURL}/bulk-data.
+func (c *Client) BulkManifest(ctx context.Context) ([]BulkData, error) {
+	body, err := c.get(ctx, c.BaseURL+"/bulk-data", "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var manifest struct {
+		Data []BulkData `json:"data"`
+	}
+	if err := json.NewDecoder(body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk-data manifest: %w", err)
+	}
+	return manifest.Data, nil
+}
+
+// Download opens a bulk file. The caller must close it.
+func (c *Client) Download(ctx context.Context, uri string) (io.ReadCloser, error) {
+	return c.get(ctx, uri, "*/*")
+}
+
+func (c *Client) get(ctx context.Context, url string, accept string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Scryfall asks API clients to identify themselves
+	req.Header.Set("User-Agent", "CardBarrage/1.0")
+	req.Header.Set("Accept", accept)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// FindBulk picks the manifest entry of the given type.
+func FindBulk(manifest []BulkData, bulkType string) (BulkData, bool) {
+	for _, entry := range manifest {
+		if entry.Type == bulkType {
+			return entry, true
+		}
+	}
+	return BulkData{}, false
+}
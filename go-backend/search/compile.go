@@ -0,0 +1,447 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Clause is a compiled WHERE condition. SQL uses ? placeholders for Args;
+// user input never ends up in SQL itself.
+type Clause struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Compile turns a parsed query into a WHERE condition over the cards table.
+func Compile(n Node) (Clause, error) {
+	var c compiler
+	sql, err := c.compile(n)
+	if err != nil {
+		return Clause{}, err
+	}
+	return Clause{SQL: sql, Args: c.args}, nil
+}
+
+// Mentions reports whether the query filters on the given key (or one of
+// its aliases), e.g. so callers only apply a default language when the
+// query doesn't choose one.
+func Mentions(n Node, key string) bool {
+	switch n := n.(type) {
+	case And:
+		for _, c := range n.Nodes {
+			if Mentions(c, key) {
+				return true
+			}
+		}
+	case Or:
+		for _, c := range n.Nodes {
+			if Mentions(c, key) {
+				return true
+			}
+		}
+	case Not:
+		return Mentions(n.Node, key)
+	case Term:
+		return aliases[n.Key] == aliases[key] && n.Key != ""
+	}
+	return false
+}
+
+// aliases maps every accepted key to its canonical name
+var aliases = map[string]string{
+	"name": "name", "n": "name",
+	"t": "type", "type": "type",
+	"o": "oracle", "oracle": "oracle",
+	"c": "color", "color": "color", "colour": "color",
+	"id": "identity", "identity": "identity", "ci": "identity",
+	"cmc": "cmc", "mv": "cmc", "manavalue": "cmc",
+	"pow": "power", "power": "power",
+	"tou": "toughness", "toughness": "toughness",
+	"loy": "loyalty", "loyalty": "loyalty",
+	"k": "keyword", "kw": "keyword", "keyword": "keyword",
+	"r": "rarity", "rarity": "rarity",
+	"s": "set", "set": "set", "e": "set", "edition": "set",
+	"a": "artist", "artist": "artist",
+	"ft": "flavor", "flavor": "flavor",
+	"lang": "lang", "language": "lang",
+	"m": "mana", "mana": "mana",
+	"year": "year", "date": "date",
+	"f": "format", "format": "format", "legal": "format",
+	"banned": "banned", "restricted": "restricted",
+	"usd": "usd", "eur": "eur", "tix": "tix",
+	"is": "is", "not": "not",
+}
+
+var sqlOps = map[string]string{
+	":": "=", "=": "=", "!=": "<>", "<": "<", "<=": "<=", ">": ">", ">=": ">=",
+}
+
+// numericColumns are the columns that can be compared as numbers, by key.
+// Stats are text ("*", "1+*"), so only plain numbers take part. Fixed SQL
+// here must not contain a question mark: GORM would take it for a
+// placeholder even inside a string literal.
+var numericColumns = map[string]string{
+	"cmc": "cmc",
+	"usd": `NULLIF(prices->>'usd', '')::numeric`,
+	"eur": `NULLIF(prices->>'eur', '')::numeric`,
+	"tix": `NULLIF(prices->>'tix', '')::numeric`,
+}
+
+// faceStats are the numeric keys that live on each face of a double-faced
+// card; %[1]s is the field's column expression (see eachFace).
+var faceStats = map[string]string{
+	"power":     `CASE WHEN %[1]s ~ '^-{0,1}[0-9]+(\.[0-9]+){0,1}$' THEN (%[1]s)::numeric END`,
+	"toughness": `CASE WHEN %[1]s ~ '^-{0,1}[0-9]+(\.[0-9]+){0,1}$' THEN (%[1]s)::numeric END`,
+	"loyalty":   `CASE WHEN %[1]s ~ '^-{0,1}[0-9]+$' THEN (%[1]s)::numeric END`,
+}
+
+// facesMana is every mana cost on the card, for predicates that only look
+// for a symbol: double-faced cards have no top-level cost.
+const facesMana = `COALESCE(mana_cost, jsonb_path_query_array(card_faces, '$[*].mana_cost')::text, '')`
+
+var textColumns = map[string]string{
+	"name":   "name",
+	"type":   "type_line",
+	"artist": "COALESCE(artist, '')",
+	"flavor": "COALESCE(flavor_text, '')",
+}
+
+var rarityRanks = map[string]int{
+	"common": 0, "c": 0,
+	"uncommon": 1, "u": 1,
+	"rare": 2, "r": 2,
+	"special": 3, "s": 3,
+	"mythic": 4, "m": 4,
+	"bonus": 5, "b": 5,
+}
+
+const rarityRank = `CASE rarity WHEN 'common' THEN 0 WHEN 'uncommon' THEN 1 WHEN 'rare' THEN 2 WHEN 'special' THEN 3 WHEN 'mythic' THEN 4 WHEN 'bonus' THEN 5 END`
+
+var colorNames = map[string]string{
+	"white": "W", "blue": "U", "black": "B", "red": "R", "green": "G",
+	"azorius": "WU", "dimir": "UB", "rakdos": "BR", "gruul": "RG", "selesnya": "GW",
+	"orzhov": "WB", "izzet": "UR", "golgari": "BG", "boros": "RW", "simic": "GU",
+	"bant": "GWU", "esper": "WUB", "grixis": "UBR", "jund": "BRG", "naya": "RGW",
+	"abzan": "WBG", "jeskai": "URW", "sultai": "BUG", "mardu": "RWB", "temur": "GUR",
+}
+
+// isPredicates are the fixed conditions behind is: and not:
+var isPredicates = map[string]string{
+	"token":      `type_line ILIKE '%Token%'`,
+	"permanent":  `type_line !~* '\m(instant|sorcery)\M'`,
+	"spell":      `type_line !~* '^(basic )?land\M'`,
+	"legendary":  `type_line ILIKE 'Legendary%'`,
+	"commander":  `(type_line ILIKE 'Legendary%Creature%' OR COALESCE(oracle_text, '') ILIKE '%can be your commander%')`,
+	"historic":   `type_line ~* '\m(legendary|artifact|saga)\M'`,
+	"multicolor": `COALESCE(cardinality(colors), 0) > 1`,
+	"monocolor":  `COALESCE(cardinality(colors), 0) = 1`,
+	"colorless":  `COALESCE(cardinality(colors), 0) = 0`,
+	"multiface":  `card_faces IS NOT NULL`,
+	// Double-faced cards have an image per face; split, flip and adventure
+	// cards share one
+	"dfc":       `card_faces IS NOT NULL AND image_uris IS NULL`,
+	"split":     `card_faces IS NOT NULL AND image_uris IS NOT NULL`,
+	"hybrid":    facesMana + ` ~ '\{[WUBRGC2]/[WUBRG]\}'`,
+	"phyrexian": facesMana + ` LIKE '%/P}%'`,
+	"vanilla":   `COALESCE(oracle_text, '') = '' AND card_faces IS NULL AND type_line ILIKE '%Creature%'`,
+}
+
+var (
+	formatName = regexp.MustCompile(`^[a-z]+$`)
+	isoDate    = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2})?)?$`)
+)
+
+type compiler struct {
+	args []interface{}
+}
+
+func (c *compiler) arg(v interface{}) string {
+	c.args = append(c.args, v)
+	return "?"
+}
+
+// eachFace matches when cond holds for the card's top-level fields or for
+// any one of its faces. cond is called once for each, with field mapping a
+// column name to its expression there, so the args it adds stay in order.
+func (c *compiler) eachFace(cond func(field func(string) string) string) string {
+	top := cond(func(name string) string { return name })
+	face := cond(func(name string) string { return "(f->>'" + name + "')" })
+	return "(" + top + " OR EXISTS (SELECT 1 FROM jsonb_array_elements(card_faces) AS f WHERE " + face + "))"
+}
+
+func (c *compiler) compile(n Node) (string, error) {
+	switch n := n.(type) {
+	case And:
+		return c.join(n.Nodes, " AND ")
+	case Or:
+		return c.join(n.Nodes, " OR ")
+	case Not:
+		inner, err := c.compile(n.Node)
+		if err != nil {
+			return "", err
+		}
+		// A NULL column makes the inner test NULL; NOT should still match it
+		return "NOT COALESCE((" + inner + "), false)", nil
+	case Term:
+		return c.term(n)
+	}
+	return "", &Error{Pos: -1, Msg: fmt.Sprintf("unsupported node %T", n)}
+}
+
+func (c *compiler) join(nodes []Node, sep string) (string, error) {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		sql, err := c.compile(n)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = sql
+	}
+	return "(" + strings.Join(parts, sep) + ")", nil
+}
+
+func (c *compiler) term(t Term) (string, error) {
+	fail := func(format string, a ...interface{}) (string, error) {
+		return "", &Error{Pos: t.Pos, Msg: fmt.Sprintf(format, a...)}
+	}
+
+	if t.Key == "" {
+		if t.Op == "!" {
+			return "lower(name) = lower(" + c.arg(t.Value) + ")", nil
+		}
+		return "name ILIKE " + c.arg(contains(t.Value)), nil
+	}
+
+	key, ok := aliases[t.Key]
+	if !ok {
+		return fail("unknown key %q", t.Key)
+	}
+	op, ok := sqlOps[t.Op]
+	if !ok {
+		return fail("unknown operator %q", t.Op)
+	}
+	textOnly := func() error {
+		if t.Op != ":" && t.Op != "=" {
+			return &Error{Pos: t.Pos, Msg: fmt.Sprintf("%s only supports : and =", t.Key)}
+		}
+		return nil
+	}
+
+	switch key {
+	case "name", "type", "artist", "flavor":
+		if err := textOnly(); err != nil {
+			return "", err
+		}
+		return textColumns[key] + " ILIKE " + c.arg(contains(t.Value)), nil
+
+	case "oracle":
+		if err := textOnly(); err != nil {
+			return "", err
+		}
+		// Multi-face cards keep their rules text on the faces
+		pattern := contains(t.Value)
+		return c.eachFace(func(field func(string) string) string {
+			return "COALESCE(" + field("oracle_text") + ", '') ILIKE " + c.arg(pattern)
+		}), nil
+
+	case "cmc", "power", "toughness", "loyalty", "usd", "eur", "tix":
+		// pow>tou compares two stats of the same card, or the same face
+		var other string
+		if o, ok := aliases[strings.ToLower(t.Value)]; ok && numericExpr(o, nil) != "" {
+			other = o
+		}
+		var n float64
+		if other == "" {
+			var err error
+			if n, err = strconv.ParseFloat(t.Value, 64); err != nil {
+				return fail("%s needs a number, got %q", t.Key, t.Value)
+			}
+		}
+		cond := func(field func(string) string) string {
+			left := "(" + numericExpr(key, field) + ") " + op + " "
+			if other != "" {
+				return left + "(" + numericExpr(other, field) + ")"
+			}
+			return left + c.arg(n)
+		}
+		if _, ok := faceStats[key]; ok {
+			return c.eachFace(cond), nil
+		}
+		if _, ok := faceStats[other]; ok {
+			return c.eachFace(cond), nil
+		}
+		return cond(nil), nil
+
+	case "color", "identity":
+		return c.colors(t, key)
+
+	case "keyword":
+		if err := textOnly(); err != nil {
+			return "", err
+		}
+		return "EXISTS (SELECT 1 FROM unnest(keywords) AS kw WHERE lower(kw) = lower(" + c.arg(t.Value) + "))", nil
+
+	case "rarity":
+		rank, ok := rarityRanks[strings.ToLower(t.Value)]
+		if !ok {
+			return fail("unknown rarity %q", t.Value)
+		}
+		return rarityRank + " " + op + " " + c.arg(rank), nil
+
+	case "set", "lang":
+		if op != "=" && op != "<>" {
+			return fail("%s only supports :, = and !=", t.Key)
+		}
+		column := map[string]string{"set": "set_code", "lang": "lang"}[key]
+		return column + " " + op + " " + c.arg(strings.ToLower(t.Value)), nil
+
+	case "mana":
+		// Either the whole cost or one face's cost can match
+		cost := strings.ToUpper(t.Value)
+		if t.Op == "=" {
+			return c.eachFace(func(field func(string) string) string {
+				return field("mana_cost") + " = " + c.arg(cost)
+			}), nil
+		}
+		if err := textOnly(); err != nil {
+			return "", err
+		}
+		return c.eachFace(func(field func(string) string) string {
+			return "COALESCE(" + field("mana_cost") + ", '') LIKE " + c.arg(contains(cost))
+		}), nil
+
+	case "year":
+		year, err := strconv.Atoi(t.Value)
+		if err != nil {
+			return fail("year needs a number, got %q", t.Value)
+		}
+		return "CAST(NULLIF(substring(released_at from 1 for 4), '') AS integer) " + op + " " + c.arg(year), nil
+
+	case "date":
+		if !isoDate.MatchString(t.Value) {
+			return fail("date needs YYYY-MM-DD, got %q", t.Value)
+		}
+		return "released_at " + op + " " + c.arg(t.Value), nil
+
+	case "format", "banned", "restricted":
+		if err := textOnly(); err != nil {
+			return "", err
+		}
+		format := strings.ToLower(t.Value)
+		if !formatName.MatchString(format) {
+			return fail("unknown format %q", t.Value)
+		}
+		status := map[string]string{"format": "legal", "banned": "banned", "restricted": "restricted"}[key]
+		return "legalities->>" + c.arg(format) + " = " + c.arg(status), nil
+
+	case "is", "not":
+		if t.Op != ":" {
+			return fail("%s only supports :", t.Key)
+		}
+		pred, ok := isPredicates[strings.ToLower(t.Value)]
+		if !ok {
+			return fail("unknown %s:%s", t.Key, t.Value)
+		}
+		if key == "not" {
+			return "NOT COALESCE((" + pred + "), false)", nil
+		}
+		return "(" + pred + ")", nil
+	}
+	return fail("unsupported key %q", t.Key)
+}
+
+// numericExpr is the SQL for a numeric key, or "" if key isn't numeric.
+// Face stats read their column through field; a nil field means the
+// top-level column.
+func numericExpr(key string, field func(string) string) string {
+	if expr, ok := numericColumns[key]; ok {
+		return expr
+	}
+	tmpl, ok := faceStats[key]
+	if !ok {
+		return ""
+	}
+	if field == nil {
+		return fmt.Sprintf(tmpl, key)
+	}
+	return fmt.Sprintf(tmpl, field(key))
+}
+
+// colors compiles c: and id:. The ":" operator means "at least these
+// colours" for c and "within this identity" for id, as on Scryfall.
+func (c *compiler) colors(t Term, key string) (string, error) {
+	column := map[string]string{"color": "colors", "identity": "color_identity"}[key]
+	op := t.Op
+	if op == ":" {
+		op = ">="
+		if key == "identity" {
+			op = "<="
+		}
+	}
+	value := strings.ToLower(t.Value)
+	count := "COALESCE(cardinality(" + column + "), 0)"
+
+	// c=2 and id<3 compare the number of colours
+	if n, err := strconv.Atoi(value); err == nil {
+		return count + " " + sqlOps[op] + " " + c.arg(n), nil
+	}
+	switch value {
+	case "c", "colorless":
+		if op == "!=" {
+			return count + " > 0", nil
+		}
+		return count + " = 0", nil
+	case "m", "multicolor":
+		if op == "!=" {
+			return count + " <= 1", nil
+		}
+		return count + " > 1", nil
+	}
+
+	letters := value
+	if named, ok := colorNames[value]; ok {
+		letters = strings.ToLower(named)
+	}
+	set := pq.StringArray{}
+	for _, l := range letters {
+		code := strings.ToUpper(string(l))
+		if !strings.Contains("WUBRG", code) {
+			return "", &Error{Pos: t.Pos, Msg: fmt.Sprintf("unknown colour %q", t.Value)}
+		}
+		if !slices.Contains(set, code) {
+			set = append(set, code)
+		}
+	}
+
+	// Each call binds its own copy of set, so only fragments that end up in
+	// the SQL add args
+	superset := func() string { return column + " @> " + c.arg(set) + "::text[]" }
+	subset := func() string { return "COALESCE(" + column + ", '{}') <@ " + c.arg(set) + "::text[]" }
+	switch op {
+	case ">=":
+		return superset(), nil
+	case "<=":
+		return subset(), nil
+	case "=":
+		return "(" + superset() + " AND " + subset() + ")", nil
+	case ">":
+		return "(" + superset() + " AND NOT " + subset() + ")", nil
+	case "<":
+		return "(" + subset() + " AND NOT " + superset() + ")", nil
+	case "!=":
+		return "NOT (" + superset() + " AND " + subset() + ")", nil
+	}
+	return "", &Error{Pos: t.Pos, Msg: fmt.Sprintf("unknown operator %q", t.Op)}
+}
+
+// contains builds an ILIKE pattern matching value anywhere, with LIKE
+// wildcards in the value taken literally.
+func contains(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(value) + "%"
+}
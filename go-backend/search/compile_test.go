@@ -0,0 +1,187 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// Fixed SQL shared by several cases
+const (
+	powerTop   = `(CASE WHEN power ~ '^-{0,1}[0-9]+(\.[0-9]+){0,1}$' THEN (power)::numeric END)`
+	powerFace  = `(CASE WHEN (f->>'power') ~ '^-{0,1}[0-9]+(\.[0-9]+){0,1}$' THEN ((f->>'power'))::numeric END)`
+	toughTop   = `(CASE WHEN toughness ~ '^-{0,1}[0-9]+(\.[0-9]+){0,1}$' THEN (toughness)::numeric END)`
+	toughFace  = `(CASE WHEN (f->>'toughness') ~ '^-{0,1}[0-9]+(\.[0-9]+){0,1}$' THEN ((f->>'toughness'))::numeric END)`
+	anyFace    = ` OR EXISTS (SELECT 1 FROM jsonb_array_elements(card_faces) AS f WHERE `
+	oracleText = `(COALESCE(oracle_text, '') ILIKE ?` + anyFace + `COALESCE((f->>'oracle_text'), '') ILIKE ?))`
+)
+
+func TestCompile(t *testing.T) {
+	colors := func(cs ...string) pq.StringArray { return pq.StringArray(cs) }
+	tests := []struct {
+		query string
+		sql   string
+		args  []interface{}
+	}{
+		// names
+		{"bolt", `name ILIKE ?`, []interface{}{"%bolt%"}},
+		{`!"Lightning Bolt"`, `lower(name) = lower(?)`, []interface{}{"Lightning Bolt"}},
+		{"n:bolt", `name ILIKE ?`, []interface{}{"%bolt%"}},
+		{"t:goblin", `type_line ILIKE ?`, []interface{}{"%goblin%"}},
+		{`a:"50%_off"`, `COALESCE(artist, '') ILIKE ?`, []interface{}{`%50\%\_off%`}},
+
+		// boolean structure
+		{"a b or c", `((name ILIKE ? AND name ILIKE ?) OR name ILIKE ?)`,
+			[]interface{}{"%a%", "%b%", "%c%"}},
+		{"a (b or c)", `(name ILIKE ? AND (name ILIKE ? OR name ILIKE ?))`,
+			[]interface{}{"%a%", "%b%", "%c%"}},
+		{"-(t:elf or t:goblin) c:g",
+			`(NOT COALESCE(((type_line ILIKE ? OR type_line ILIKE ?)), false) AND colors @> ?::text[])`,
+			[]interface{}{"%elf%", "%goblin%", colors("G")}},
+		{"not c:r", `NOT COALESCE((colors @> ?::text[]), false)`, []interface{}{colors("R")}},
+
+		// face-aware fields
+		{"o:draw", oracleText, []interface{}{"%draw%", "%draw%"}},
+		{"pow>=4", "(" + powerTop + " >= ?" + anyFace + powerFace + " >= ?))", []interface{}{4.0, 4.0}},
+		{"pow>tou", "(" + powerTop + " > " + toughTop + anyFace + powerFace + " > " + toughFace + "))", nil},
+		{"cmc>pow", "((cmc) > " + powerTop + anyFace + "(cmc) > " + powerFace + "))", nil},
+		{"loy=3",
+			`((CASE WHEN loyalty ~ '^-{0,1}[0-9]+$' THEN (loyalty)::numeric END) = ?` + anyFace +
+				`(CASE WHEN (f->>'loyalty') ~ '^-{0,1}[0-9]+$' THEN ((f->>'loyalty'))::numeric END) = ?))`,
+			[]interface{}{3.0, 3.0}},
+		{"m:{G/P}",
+			`(COALESCE(mana_cost, '') LIKE ?` + anyFace + `COALESCE((f->>'mana_cost'), '') LIKE ?))`,
+			[]interface{}{"%{G/P}%", "%{G/P}%"}},
+		{"m={1}{r}", `(mana_cost = ?` + anyFace + `(f->>'mana_cost') = ?))`,
+			[]interface{}{"{1}{R}", "{1}{R}"}},
+
+		// numbers
+		{"cmc<=3", `(cmc) <= ?`, []interface{}{3.0}},
+		{"mv=2", `(cmc) = ?`, []interface{}{2.0}},
+		{"cmc!=0", `(cmc) <> ?`, []interface{}{0.0}},
+		{"usd>1.5", `(NULLIF(prices->>'usd', '')::numeric) > ?`, []interface{}{1.5}},
+		{"year>=2020", `CAST(NULLIF(substring(released_at from 1 for 4), '') AS integer) >= ?`, []interface{}{2020}},
+		{"date<2020-01-01", `released_at < ?`, []interface{}{"2020-01-01"}},
+		{"r>=rare", rarityRank + ` >= ?`, []interface{}{2}},
+
+		// colours: ":" is >= for c and <= for id
+		{"c:wu", `colors @> ?::text[]`, []interface{}{colors("W", "U")}},
+		{"c>=rg", `colors @> ?::text[]`, []interface{}{colors("R", "G")}},
+		{"c<=esper", `COALESCE(colors, '{}') <@ ?::text[]`, []interface{}{colors("W", "U", "B")}},
+		{"c=r", `(colors @> ?::text[] AND COALESCE(colors, '{}') <@ ?::text[])`,
+			[]interface{}{colors("R"), colors("R")}},
+		{"c>wu", `(colors @> ?::text[] AND NOT COALESCE(colors, '{}') <@ ?::text[])`,
+			[]interface{}{colors("W", "U"), colors("W", "U")}},
+		{"c<rg", `(COALESCE(colors, '{}') <@ ?::text[] AND NOT colors @> ?::text[])`,
+			[]interface{}{colors("R", "G"), colors("R", "G")}},
+		{"c!=g", `NOT (colors @> ?::text[] AND COALESCE(colors, '{}') <@ ?::text[])`,
+			[]interface{}{colors("G"), colors("G")}},
+		{"c:uwu", `colors @> ?::text[]`, []interface{}{colors("U", "W")}},
+		{"id:esper", `COALESCE(color_identity, '{}') <@ ?::text[]`, []interface{}{colors("W", "U", "B")}},
+		{"id<=esper", `COALESCE(color_identity, '{}') <@ ?::text[]`, []interface{}{colors("W", "U", "B")}},
+		{"ci>=g", `color_identity @> ?::text[]`, []interface{}{colors("G")}},
+		{"c:c", `COALESCE(cardinality(colors), 0) = 0`, nil},
+		{"c!=colorless", `COALESCE(cardinality(colors), 0) > 0`, nil},
+		{"c:m", `COALESCE(cardinality(colors), 0) > 1`, nil},
+		{"c!=m", `COALESCE(cardinality(colors), 0) <= 1`, nil},
+		{"c=2", `COALESCE(cardinality(colors), 0) = ?`, []interface{}{2}},
+		{"id<3", `COALESCE(cardinality(color_identity), 0) < ?`, []interface{}{3}},
+
+		// other keys
+		{"k:flying", `EXISTS (SELECT 1 FROM unnest(keywords) AS kw WHERE lower(kw) = lower(?))`, []interface{}{"flying"}},
+		{"s:M10", `set_code = ?`, []interface{}{"m10"}},
+		{"lang!=ja", `lang <> ?`, []interface{}{"ja"}},
+		{"f:modern", `legalities->>? = ?`, []interface{}{"modern", "legal"}},
+		{"banned:legacy", `legalities->>? = ?`, []interface{}{"legacy", "banned"}},
+		{"restricted:vintage", `legalities->>? = ?`, []interface{}{"vintage", "restricted"}},
+		{"is:token", `(type_line ILIKE '%Token%')`, nil},
+		{"not:dfc", `NOT COALESCE((card_faces IS NOT NULL AND image_uris IS NULL), false)`, nil},
+
+		// args stay lined up with their placeholders across terms
+		{"c:wu cmc<=3", `(colors @> ?::text[] AND (cmc) <= ?)`, []interface{}{colors("W", "U"), 3.0}},
+		{`c>=rg cmc<=3 o:"draw a card" -is:token`,
+			`(colors @> ?::text[] AND (cmc) <= ? AND ` + oracleText +
+				` AND NOT COALESCE(((type_line ILIKE '%Token%')), false))`,
+			[]interface{}{colors("R", "G"), 3.0, "%draw a card%", "%draw a card%"}},
+		{"id<=esper c<rg t:elf",
+			`(COALESCE(color_identity, '{}') <@ ?::text[] AND (COALESCE(colors, '{}') <@ ?::text[] AND NOT colors @> ?::text[]) AND type_line ILIKE ?)`,
+			[]interface{}{colors("W", "U", "B"), colors("R", "G"), colors("R", "G"), "%elf%"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			n, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Compile(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.SQL != tt.sql {
+				t.Errorf("SQL\n got %s\nwant %s", got.SQL, tt.sql)
+			}
+			if !reflect.DeepEqual(got.Args, tt.args) {
+				t.Errorf("args = %#v, want %#v", got.Args, tt.args)
+			}
+			if n := strings.Count(got.SQL, "?"); n != len(got.Args) {
+				t.Errorf("%d placeholders for %d args", n, len(got.Args))
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		msg   string
+	}{
+		{"foo:bar", `unknown key "foo"`},
+		{"t>goblin", "t only supports : and ="},
+		{"o!=draw", "o only supports : and ="},
+		{"cmc>=abc", `cmc needs a number, got "abc"`},
+		{"pow:tou3", `pow needs a number, got "tou3"`},
+		{"c:xyz", `unknown colour "xyz"`},
+		{"id<=purple", `unknown colour "purple"`},
+		{"r:foo", `unknown rarity "foo"`},
+		{"s>m10", "s only supports :, = and !="},
+		{"k>=flying", "k only supports : and ="},
+		{"m>{R}", "m only supports : and ="},
+		{"year:abc", `year needs a number, got "abc"`},
+		{"date:2020/01", `date needs YYYY-MM-DD, got "2020/01"`},
+		{"f:mod3rn", `unknown format "mod3rn"`},
+		{"is>token", "is only supports :"},
+		{"is:foo", "unknown is:foo"},
+		{"a -not:bar", "unknown not:bar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			n, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Compile(n)
+			var qe *Error
+			if !errors.As(err, &qe) {
+				t.Fatalf("got %#v, %v; want a query error", got, err)
+			}
+			if qe.Msg != tt.msg {
+				t.Errorf("got %q, want %q", qe.Msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	n, err := Parse("bolt (language:ja or -s:m10)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"lang": true, "set": true, "e": true, "name": false, "type": false} {
+		if got := Mentions(n, key); got != want {
+			t.Errorf("Mentions(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
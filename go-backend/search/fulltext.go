@@ -0,0 +1,83 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TSQuery converts a full-text query into Postgres to_tsquery syntax.
+// Words are ANDed; "quoted words" must appear as a phrase, a leading "-"
+// excludes a word or phrase, a trailing "*" matches a prefix and OR
+// between terms matches either side. For example
+//
+//	"draw a card" sacrifice* -discard
+//
+// becomes (draw <-> a <-> card) & sacrifice:* & !discard. Punctuation is
+// dropped, so input can never break out of the tsquery syntax.
+func TSQuery(query string) (string, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	negate, or := false, false
+	for _, t := range tokens {
+		switch t.kind {
+		case tokEOF:
+		case tokNot:
+			negate = true
+		case tokOr:
+			if b.Len() == 0 || or {
+				return "", &Error{Pos: t.pos, Msg: "OR needs a term on each side"}
+			}
+			or = true
+		case tokLParen, tokRParen:
+			return "", &Error{Pos: t.pos, Msg: "parentheses are not supported in text search"}
+		case tokTerm:
+			term := tsTerm(t.text)
+			if term == "" {
+				negate = false
+				continue
+			}
+			if b.Len() > 0 {
+				if or {
+					b.WriteString(" | ")
+				} else {
+					b.WriteString(" & ")
+				}
+			}
+			if negate {
+				b.WriteString("!")
+			}
+			b.WriteString(term)
+			negate, or = false, false
+		}
+	}
+	if or {
+		return "", &Error{Pos: len(query), Msg: "OR needs a term on each side"}
+	}
+	if b.Len() == 0 {
+		return "", &Error{Pos: 0, Msg: "empty query"}
+	}
+	return b.String(), nil
+}
+
+// tsTerm renders one word or quoted phrase as a tsquery operand; a
+// trailing "*" applies to the last word of a phrase.
+func tsTerm(text string) string {
+	prefix := strings.HasSuffix(text, "*")
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	if prefix {
+		words[len(words)-1] += ":*"
+	}
+	if len(words) == 1 {
+		return words[0]
+	}
+	return "(" + strings.Join(words, " <-> ") + ")"
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTSQuery(t *testing.T) {
+	tests := []struct{ query, want string }{
+		{"sacrifice", "sacrifice"},
+		{"draw card", "draw & card"},
+		{`"draw a card" sacrifice* -discard`, "(draw <-> a <-> card) & sacrifice:* & !discard"},
+		{`"draw a card"`, "(draw <-> a <-> card)"},
+		{`-"draw a"`, "!(draw <-> a)"},
+		{"not discard", "!discard"},
+		{"destr*", "destr:*"},
+		{`"enters the"*`, "(enters <-> the:*)"},
+		{"-destr*", "!destr:*"},
+		{"flying or reach", "flying | reach"},
+		// & binds tighter than | in tsquery, as AND does in search queries
+		{"flying OR reach trample", "flying | reach & trample"},
+		{"flying or -reach", "flying | !reach"},
+		{"draw AND card", "draw & card"},
+		// punctuation splits words and can't inject operators
+		{"it's", "(it <-> s)"},
+		{"a & !b | c:*", "a & b & c:*"},
+		{"a - b", "a & b"},
+		{"-& a", "a"},
+		{"Æther über", "Æther & über"},
+	}
+	for _, tt := range tests {
+		got, err := TSQuery(tt.query)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("TSQuery(%s) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTSQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"", 0, "empty query"},
+		{"&& !!", 0, "empty query"},
+		{"or flying", 0, "OR needs a term on each side"},
+		{"flying or", 9, "OR needs a term on each side"},
+		{"flying or or reach", 10, "OR needs a term on each side"},
+		{"(flying)", 0, "parentheses are not supported in text search"},
+		{"flying (reach)", 7, "parentheses are not supported in text search"},
+		{`"draw a`, 0, "unterminated quote"},
+	}
+	for _, tt := range tests {
+		got, err := TSQuery(tt.query)
+		var qe *Error
+		if !errors.As(err, &qe) {
+			t.Errorf("%s: got %q, %v; want a query error", tt.query, got, err)
+			continue
+		}
+		if qe.Pos != tt.pos || qe.Msg != tt.msg {
+			t.Errorf("%s: got %d %q, want %d %q", tt.query, qe.Pos, qe.Msg, tt.pos, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package search parses Scryfall-style card queries such as
+// `t:creature c>=rg cmc<=3 o:"draw a card" -is:token` and compiles them
+// into parameterised SQL over the cards table.
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Node is a parsed query expression.
+type Node interface{ node() }
+
+// And matches when every child matches. Adjacent terms are ANDed.
+type And struct{ Nodes []Node }
+
+// Or matches when any child matches.
+type Or struct{ Nodes []Node }
+
+// Not inverts its child; written as a leading "-" or the word NOT.
+type Not struct{ Node Node }
+
+// Term is a single filter. Key is empty for a bare word, which matches
+// against the card name; Op is "!" for an exact name match.
+type Term struct {
+	Key   string
+	Op    string
+	Value string
+	Pos   int // byte offset in the query, for error messages
+}
+
+func (And) node()  {}
+func (Or) node()   {}
+func (Not) node()  {}
+func (Term) node() {}
+
+// Error reports a query the user got wrong: bad syntax, an unknown key or
+// a value that doesn't fit the key. Pos is the byte offset when known.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if e.Pos < 0 {
+		return "invalid query: " + e.Msg
+	}
+	return fmt.Sprintf("invalid query at %d: %s", e.Pos, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokTerm tokenKind = iota
+	tokLParen
+	tokRParen
+	tokNot
+	tokOr
+	tokEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var termPattern = regexp.MustCompile(`^([A-Za-z]+)(>=|<=|!=|:|=|<|>)(.*)$`)
+
+// Parse turns a query string into an expression tree.
+func Parse(query string) (Node, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &Error{Pos: 0, Msg: "empty query"}
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return n, nil
+}
+
+func lex(query string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == '-' && i+1 < len(query) && query[i+1] != ' ':
+			tokens = append(tokens, token{tokNot, "-", i})
+			i++
+		default:
+			start := i
+			inQuote := false
+			for i < len(query) {
+				c := query[i]
+				if c == '"' {
+					inQuote = !inQuote
+				} else if !inQuote && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')') {
+					break
+				}
+				i++
+			}
+			if inQuote {
+				return nil, &Error{Pos: start, Msg: "unterminated quote"}
+			}
+			text := query[start:i]
+			switch strings.ToLower(text) {
+			case "or":
+				tokens = append(tokens, token{tokOr, text, start})
+			case "and":
+				// AND is implied between terms
+			case "not":
+				tokens = append(tokens, token{tokNot, text, start})
+			default:
+				tokens = append(tokens, token{tokTerm, text, start})
+			}
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(query)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var nodes []Node
+	for {
+		switch p.peek().kind {
+		case tokEOF, tokRParen, tokOr:
+			if len(nodes) == 0 {
+				t := p.peek()
+				return nil, &Error{Pos: t.pos, Msg: "expected a search term"}
+			}
+			if len(nodes) == 1 {
+				return nodes[0], nil
+			}
+			return And{Nodes: nodes}, nil
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNot:
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: n}, nil
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &Error{Pos: closing.pos, Msg: "missing closing parenthesis"}
+		}
+		return n, nil
+	case tokTerm:
+		return parseTerm(t)
+	default:
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+}
+
+func parseTerm(t token) (Node, error) {
+	text := t.text
+	if rest, ok := strings.CutPrefix(text, "!"); ok {
+		name := unquote(rest)
+		if name == "" {
+			return nil, &Error{Pos: t.pos, Msg: "empty exact name"}
+		}
+		return Term{Op: "!", Value: name, Pos: t.pos}, nil
+	}
+	if m := termPattern.FindStringSubmatch(text); m != nil {
+		value := unquote(m[3])
+		if value == "" {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("%s%s needs a value", m[1], m[2])}
+		}
+		return Term{Key: strings.ToLower(m[1]), Op: m[2], Value: value, Pos: t.pos}, nil
+	}
+	return Term{Op: ":", Value: unquote(text), Pos: t.pos}, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return strings.ReplaceAll(s, `"`, "")
+}
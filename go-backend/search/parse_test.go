@@ -0,0 +1,100 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	term := func(key, op, value string, pos int) Term {
+		return Term{Key: key, Op: op, Value: value, Pos: pos}
+	}
+	tests := []struct {
+		query string
+		want  Node
+	}{
+		{"bolt", term("", ":", "bolt", 0)},
+		{"lightning bolt", And{Nodes: []Node{term("", ":", "lightning", 0), term("", ":", "bolt", 10)}}},
+		{"a and b", And{Nodes: []Node{term("", ":", "a", 0), term("", ":", "b", 6)}}},
+		// AND binds tighter than OR
+		{"a b or c", Or{Nodes: []Node{
+			And{Nodes: []Node{term("", ":", "a", 0), term("", ":", "b", 2)}},
+			term("", ":", "c", 7),
+		}}},
+		{"a OR b or c", Or{Nodes: []Node{term("", ":", "a", 0), term("", ":", "b", 5), term("", ":", "c", 10)}}},
+		{"(a or b) c", And{Nodes: []Node{
+			Or{Nodes: []Node{term("", ":", "a", 1), term("", ":", "b", 6)}},
+			term("", ":", "c", 9),
+		}}},
+		{"a (b or (c d))", And{Nodes: []Node{
+			term("", ":", "a", 0),
+			Or{Nodes: []Node{
+				term("", ":", "b", 3),
+				And{Nodes: []Node{term("", ":", "c", 9), term("", ":", "d", 11)}},
+			}},
+		}}},
+		{"-t:goblin", Not{Node: term("t", ":", "goblin", 1)}},
+		{"not c:r", Not{Node: term("c", ":", "r", 4)}},
+		{"NOT -is:token", Not{Node: Not{Node: term("is", ":", "token", 5)}}},
+		{"-(t:elf or t:goblin)", Not{Node: Or{Nodes: []Node{term("t", ":", "elf", 2), term("t", ":", "goblin", 11)}}}},
+		{"- bolt", And{Nodes: []Node{term("", ":", "-", 0), term("", ":", "bolt", 2)}}},
+		{`o:"draw a card"`, term("o", ":", "draw a card", 0)},
+		{`"ancestral recall"`, term("", ":", "ancestral recall", 0)},
+		{`!"Lightning Bolt"`, term("", "!", "Lightning Bolt", 0)},
+		{"!Opt", term("", "!", "Opt", 0)},
+		{"T:Goblin", term("t", ":", "Goblin", 0)},
+		{"foo:bar", term("foo", ":", "bar", 0)},
+		{"cmc>=3", term("cmc", ">=", "3", 0)},
+		{"cmc<=3", term("cmc", "<=", "3", 0)},
+		{"pow>tou", term("pow", ">", "tou", 0)},
+		{"tou<2", term("tou", "<", "2", 0)},
+		{"c!=g", term("c", "!=", "g", 0)},
+		{"mv=2", term("mv", "=", "2", 0)},
+		{"m:{2}{W/U}", term("m", ":", "{2}{W/U}", 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"", 0, "empty query"},
+		{"   ", 0, "empty query"},
+		{"(a", 2, "missing closing parenthesis"},
+		{"a)", 1, `unexpected ")"`},
+		{"()", 1, "expected a search term"},
+		{`o:"draw`, 0, "unterminated quote"},
+		{"or a", 0, "expected a search term"},
+		{"a or", 4, "expected a search term"},
+		{"a or or b", 5, "expected a search term"},
+		{"t:", 0, "t: needs a value"},
+		{`o:""`, 0, "o: needs a value"},
+		{`!""`, 0, "empty exact name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			n, err := Parse(tt.query)
+			var qe *Error
+			if !errors.As(err, &qe) {
+				t.Fatalf("got %#v, %v; want a query error", n, err)
+			}
+			if qe.Pos != tt.pos || qe.Msg != tt.msg {
+				t.Errorf("got %d %q, want %d %q", qe.Pos, qe.Msg, tt.pos, tt.msg)
+			}
+		})
+	}
+}
@@ -40,7 +40,7 @@ export default function CardPage({ params }) {
       console.debug(payload);
       return await API.post('/cards/mems', payload);
     },
-    onSuccess: (data) => setSimilar(data),
+    onSuccess: (data) => setSimilar(data?.data ?? []),
   });
 
   const variantMutation = useMutation({
@@ -52,15 +52,23 @@ export default function CardPage({ params }) {
       console.debug(payload);
       return await API.post('/cards/variants', payload);
     },
-    onSuccess: (data) => setVariants(data),
+    onSuccess: (data) => setVariants(data?.data ?? []),
   });
   const cards = useMemo(() => {
     if (!data) return undefined;
-    const images = JSON.parse(data?.ImageURIs);
-    const out = !!data?.CardFaces
-      ? JSON.parse(data?.CardFaces)
-      : [{ ...data, ImageURIs: images }];
-    return out;
+    if (!data?.CardFaces) return [data];
+    // Faces use Scryfall's snake_case keys; give each the card's shape so
+    // the rest of the page reads one set of names
+    return data.CardFaces.map((face) => ({
+      ...data,
+      Name: face.name,
+      ManaCost: face.mana_cost,
+      TypeLine: face.type_line,
+      OracleText: face.oracle_text,
+      Power: face.power,
+      Toughness: face.toughness,
+      ImageURIs: face.image_uris ?? data.ImageURIs,
+    }));
   }, [data]);
 
   useEffect(() => {
@@ -70,7 +78,7 @@ export default function CardPage({ params }) {
   }, [cards]);
 
   const filterType = cards?.map((c) => {
-    const s = c.TypeLine.split(' — ');
+    const s = (c.TypeLine ?? '').split(' — ');
     return s[0] ?? '';
   });
 
@@ -112,7 +120,7 @@ export default function CardPage({ params }) {
               </div>
 
               <div className="flex flex-col text-4xl min-w-fill items-left p-4 m-4 gap-4 border rounded-2xl bg-card whitespace-pre-line">
-                <OracleText text={data?.OracleText} size="lg" />
+                <OracleText text={card?.OracleText} size="lg" />
               </div>
               <div
                 className={`${card?.Power && card?.Toughness ? '' : 'hidden'} flex flex-col text-4xl min-w-fill items-left p-4 m-4 gap-4 border rounded-2xl bg-card whitespace-pre-line`}
@@ -130,7 +138,7 @@ export default function CardPage({ params }) {
           </AccordionTrigger>
           <AccordionContent>
             <Display
-              cards={similarMutation?.data}
+              cards={similarMutation?.data?.data}
               isLoading={isLoading || similarMutation.isPending}
               filterType={filterType}
               colorIdentity={colorIdentity}
@@ -143,7 +151,7 @@ export default function CardPage({ params }) {
           </AccordionTrigger>
           <AccordionContent>
             <Display
-              cards={variantMutation?.data}
+              cards={variantMutation?.data?.data}
               isLoading={isLoading || variantMutation.isPending}
               filterType={filterType}
               colorIdentity={colorIdentity}
@@ -28,7 +28,7 @@ export default function RandomCard() {
     setSimilar([]);
   }, [randomMutation.data]);
   useEffect(() => {
-    setSimilar(similarMutation.data);
+    setSimilar(similarMutation.data?.data);
   }, [similarMutation.data]);
   const handleGetRandom = () => {
     randomMutation.mutate();
@@ -29,7 +29,7 @@ export default function Display({
           break;
         case 'type':
           out = out.filter((c) => {
-            const lt = c.TypeLine.split(' — ');
+            const lt = (c.TypeLine ?? c.type_line ?? '').split(' — ');
             return filterType.includes(lt[0]);
           });
           break;
@@ -20,9 +20,9 @@ export default function MtgCard({ data, isLoading = false }) {
   const router = useRouter();
   const imageUri = useMemo(() => {
     if (!data) return undefined;
-    const images = JSON.parse(data?.ImageURIs);
+    const images = data?.ImageURIs;
     const image = images != null ? images?.normal : undefined;
-    const cardFaces = JSON.parse(data?.CardFaces);
+    const cardFaces = data?.CardFaces;
     const cardFacesUris =
       cardFaces != null
         ? cardFaces?.map((val) => val?.image_uris?.normal)